@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/openmetrics"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/tap"
+)
+
+// Formats of the -output file.
+const (
+	formatCTRF        = "ctrf"
+	formatOpenMetrics = "openmetrics"
+	formatTAP         = "tap"
+)
+
+// formats lists the supported formats, for usage messages.
+var formats = []string{formatCTRF, formatOpenMetrics, formatTAP}
+
+// isFormat tells if format is a supported format of the -output file.
+func isFormat(format string) bool {
+	for _, supported := range formats {
+		if format == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeReport writes the report to the -output file, in the -format.
+func writeReport(cmd *commandContext, report *ctrf.Report) error {
+	switch cmd.format {
+	case formatOpenMetrics:
+		if err := openmetrics.WriteFile(cmd.outputFile, report); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.writer, "go-ctrf-json-reporter: successfully written openmetrics to", cmd.outputFile)
+
+		return nil
+	case formatTAP:
+		if err := tap.WriteFile(cmd.outputFile, report); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.writer, "go-ctrf-json-reporter: successfully written tap to", cmd.outputFile)
+
+		return nil
+	default:
+		if err := report.ConvertTo(cmd.specVersion); err != nil {
+			return err
+		}
+		if err := writeCTRFReport(cmd, report); err != nil {
+			return err
+		}
+
+		// The summary of older versions counts the flaky tests apart: the exit policy and the exports
+		// follow the counts of the current version.
+		return report.ConvertTo(ctrf.SpecVersionCTRF)
+	}
+}
+
+// writeCTRFReport writes the report to the -output file, even when it is invalid with -writeInvalid.
+func writeCTRFReport(cmd *commandContext, report *ctrf.Report) error {
+	if !cmd.writeInvalid {
+		return writeCTRFFile(cmd, cmd.outputFile, report)
+	}
+
+	opts := ctrf.WriteOptions{Pretty: true, WriteInvalid: true, RecordProblems: true}
+	if !cmd.quiet {
+		opts.Warnings = cmd.errWriter
+	}
+	if err := report.WriteFileWith(cmd.outputFile, opts); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.writer, "go-ctrf-json-reporter: successfully written ctrf json to", cmd.outputFile)
+
+	return nil
+}
+
+// writeCTRFFile writes a CTRF report to a file, and tells so on the output of the command.
+func writeCTRFFile(cmd *commandContext, filename string, report *ctrf.Report) error {
+	if err := report.WriteFile(filename); err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.writer, "go-ctrf-json-reporter: successfully written ctrf json to", filename)
+
+	return nil
+}
+
+// pushMetrics pushes the metrics of the report to the -pushgateway.
+func pushMetrics(cmd *commandContext, report *ctrf.Report) error {
+	ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+	defer cancel()
+
+	return openmetrics.Push(ctx, http.DefaultClient, cmd.pushgateway, cmd.pushgatewayJob, nil, report)
+}
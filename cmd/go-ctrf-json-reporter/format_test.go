@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteOpenMetrics(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	var path, pushed string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		path, pushed = r.URL.Path, string(body)
+	}))
+	defer gateway.Close()
+
+	fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+	require.NoError(t, err)
+	defer func() {
+		_ = fixture.Close()
+	}()
+	outputFile := filepath.Join(tempDir, "metrics.txt")
+
+	err = execute(freshContext(nil, fixture), []string{
+		"-output", outputFile,
+		"-format", "openmetrics",
+		"-appName", "my-app",
+		"-pushgateway", gateway.URL,
+		"-pushgatewayJob", "unit",
+	})
+	require.NoError(t, err)
+
+	metrics, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Contains(t, string(metrics), `ctrf_tests_total{app_name="my-app"} `)
+	require.True(t, strings.HasSuffix(string(metrics), "# EOF\n"))
+
+	require.Equal(t, "/metrics/job/unit", path)
+	require.Contains(t, pushed, "# TYPE ctrf_tests_total counter")
+}
+
+func TestExecuteTAP(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+	require.NoError(t, err)
+	defer func() {
+		_ = fixture.Close()
+	}()
+	outputFile := filepath.Join(t.TempDir(), "report.tap")
+
+	err = execute(freshContext(nil, fixture), []string{"-output", outputFile, "-format", "tap"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "TAP version 14\n1.."))
+	require.Contains(t, string(data), "\nok 1 - ")
+}
+
+func TestExecuteTAPSkipReason(t *testing.T) {
+	t.Parallel()
+
+	input := `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":"pkg/a","Test":"TestSkip"}
+{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"pkg/a","Test":"TestSkip","Output":"=== RUN   TestSkip\n"}
+{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"pkg/a","Test":"TestSkip","Output":"    a_test.go:20: needs a database\n"}
+{"Time":"2024-01-01T00:00:00Z","Action":"output","Package":"pkg/a","Test":"TestSkip","Output":"--- SKIP: TestSkip (0.00s)\n"}
+{"Time":"2024-01-01T00:00:00Z","Action":"skip","Package":"pkg/a","Test":"TestSkip","Elapsed":0}
+{"Time":"2024-01-01T00:00:00Z","Action":"pass","Package":"pkg/a","Elapsed":0}
+`
+	outputFile := filepath.Join(t.TempDir(), "report.tap")
+
+	err := execute(freshContext(nil, strings.NewReader(input)), []string{"-output", outputFile, "-format", "tap"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "\nok 1 - pkg/a > TestSkip # SKIP a_test.go:20: needs a database\n")
+}
+
+func TestExecuteSpecVersion(t *testing.T) {
+	t.Parallel()
+
+	fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+	require.NoError(t, err)
+	defer func() {
+		_ = fixture.Close()
+	}()
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+
+	err = execute(freshContext(nil, fixture), []string{"-output", outputFile, "-specVersion", ctrf.SpecVersion0})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var written struct {
+		SpecVersion string `json:"specVersion"`
+		Results     struct {
+			Summary map[string]any `json:"summary"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(data, &written))
+	require.Equal(t, ctrf.SpecVersion0, written.SpecVersion)
+	require.NotContains(t, written.Results.Summary, "duration")
+
+	err = execute(freshContext(nil, strings.NewReader("")), []string{"-specVersion", "3.0.0"})
+	require.ErrorContains(t, err, `unsupported -specVersion "3.0.0"`)
+}
+
+func TestExecuteWriteInvalid(t *testing.T) {
+	t.Parallel()
+
+	outputFile := filepath.Join(t.TempDir(), "report.json")
+	ctx := freshContext(nil, strings.NewReader(""))
+
+	err := execute(ctx, []string{"-output", outputFile, "-writeInvalid"})
+	require.NoError(t, err)
+	require.Contains(t, ctx.errWriter.(fmt.Stringer).String(), "warning: invalid report: results.tests: missing property 'results.tests'")
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var written struct {
+		Extra struct {
+			ValidationErrors []ctrf.ValidationError `json:"validationErrors"`
+		} `json:"extra"`
+	}
+	require.NoError(t, json.Unmarshal(data, &written))
+	require.Equal(t, []ctrf.ValidationError{
+		{Path: "results.tests", Code: ctrf.ValidationMissing, Message: "missing property 'results.tests'"},
+	}, written.Extra.ValidationErrors)
+}
+
+func TestExecuteUnsupportedFormat(t *testing.T) {
+	t.Parallel()
+
+	err := execute(freshContext(nil, strings.NewReader("")), []string{"-format", "xml"})
+	require.ErrorContains(t, err, `unsupported -format "xml"`)
+	require.Equal(t, exitReporterError, exitCode(err))
+}
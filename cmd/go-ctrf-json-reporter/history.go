@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// historyFlags stores the flags of the history store, shared by the default command and the history subcommand.
+type historyFlags struct {
+	historyDir    string
+	historyRuns   int
+	flakyFlipRate float64
+}
+
+func registerHistoryFlags(fs *flag.FlagSet, flags *historyFlags) {
+	fs.StringVar(&flags.historyDir, "history", "", "A directory where the outcome of past runs is kept, to detect flaky tests across runs.")
+	fs.IntVar(&flags.historyRuns, "historyRuns", 20, "The number of past runs to take into account from the history.")
+	fs.Float64Var(&flags.flakyFlipRate, "flakyFlipRate", 0.3, "The rate at which the outcome of a test changes across runs for it to be considered flaky.")
+}
+
+// applyHistory marks the tests that the history, along with the run of the report, shows to be flaky.
+//
+// It returns the run of the report, as it was before the tests were marked, for ingestHistory to record it
+// once the report is written: a run which report could not be written is not part of the history.
+func applyHistory(flags historyFlags, report *ctrf.Report) (*history.Run, error) {
+	store, err := history.Open(flags.historyDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := store.Runs(flags.historyRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	run := history.NewRun(report)
+	runs = append(runs, run)
+	if flags.historyRuns > 0 && len(runs) > flags.historyRuns {
+		runs = runs[len(runs)-flags.historyRuns:]
+	}
+
+	for test, stats := range history.MarkFlaky(report, history.Stats(runs), flags.flakyFlipRate) {
+		stats := stats
+		if err = reporter.UpdateResultExtra(test, func(extra *reporter.TestExtra) { extra.History = stats }); err != nil {
+			return nil, err
+		}
+	}
+
+	return &run, nil
+}
+
+// ingestHistory appends a run to the history store.
+func ingestHistory(flags historyFlags, run *history.Run) error {
+	store, err := history.Open(flags.historyDir)
+	if err != nil {
+		return err
+	}
+
+	return store.Append(*run)
+}
+
+// executeHistory prints the statistics of the tests in the history store.
+func executeHistory(cmd *commandContext, args []string) error {
+	var (
+		flags       historyFlags
+		testPattern string
+		minFlipRate float64
+	)
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter history", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	registerHistoryFlags(fs, &flags)
+	fs.StringVar(&testPattern, "test", "", "Only show the tests which name (qualified by their package) matches this regular expression.")
+	fs.Float64Var(&minFlipRate, "minFlipRate", 0, "Only show the tests with at least this flip rate.")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if flags.historyDir == "" {
+		return &usageError{err: fmt.Errorf("the -history directory is required"), usage: usage(fs)}
+	}
+	testRegexp, err := regexp.Compile(testPattern)
+	if err != nil {
+		return &usageError{err: fmt.Errorf("invalid -test pattern: %w", err), usage: usage(fs)}
+	}
+
+	store, err := history.OpenExisting(flags.historyDir)
+	if err != nil {
+		return err
+	}
+	runs, err := store.Runs(flags.historyRuns)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "FLIP RATE\tRUNS\tFAILURES\tFLAKY\t  TEST\n")
+	for _, stats := range history.Stats(runs) {
+		key := history.Key(stats.Suite, stats.Name)
+		if stats.FlipRate < minFlipRate || !testRegexp.MatchString(key) {
+			continue
+		}
+
+		flaky := ""
+		if stats.IsFlaky(flags.flakyFlipRate) {
+			flaky = "yes"
+		}
+		fmt.Fprintf(w, "%.1f%%\t%d\t%d\t%s\t  %s\n", 100*stats.FlipRate, stats.Runs, stats.Failures, flaky, key)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteHistory(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	historyDir := filepath.Join(tempDir, "history")
+
+	t.Run("should ingest the report into the history", func(t *testing.T) {
+		fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+		require.NoError(t, err)
+		defer func() {
+			_ = fixture.Close()
+		}()
+		output := filepath.Join(tempDir, "test-report-history.json")
+
+		err = execute(freshContext(nil, fixture), []string{"-history", historyDir, "-output", output})
+		require.NoError(t, err)
+
+		store, err := history.Open(historyDir)
+		require.NoError(t, err)
+		runs, err := store.Runs(0)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		require.NotEmpty(t, runs[0].Tests)
+	})
+
+	t.Run("should print flip rates", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "flips")
+		store, err := history.Open(dir)
+		require.NoError(t, err)
+		for _, status := range []ctrf.TestStatus{ctrf.TestPassed, ctrf.TestFailed, ctrf.TestPassed} {
+			report := ctrf.NewReport("gotest", nil)
+			report.Results.Tests = []*ctrf.TestResult{
+				{Suite: []string{"pkg"}, Name: "TestFlaky", Status: status},
+				{Suite: []string{"pkg"}, Name: "TestStable", Status: ctrf.TestPassed},
+			}
+			require.NoError(t, store.Ingest(report))
+		}
+
+		var stdout bytes.Buffer
+		require.NoError(t, execute(freshContext(&stdout, nil), []string{"history", "-history", dir, "-minFlipRate", "0.5"}))
+		require.Equal(t, `  FLIP RATE  RUNS  FAILURES  FLAKY  TEST
+     100.0%     3         1    yes  pkg.TestFlaky
+`, stdout.String())
+	})
+
+	t.Run("should count the flaky tests of the history in the summary", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "maxFlaky")
+		store, err := history.Open(dir)
+		require.NoError(t, err)
+		for _, status := range []ctrf.TestStatus{ctrf.TestPassed, ctrf.TestFailed} {
+			report := ctrf.NewReport("gotest", nil)
+			report.Results.Tests = []*ctrf.TestResult{{Suite: []string{"pkg"}, Name: "TestFlaky", Status: status}}
+			require.NoError(t, store.Ingest(report))
+		}
+		output := filepath.Join(tempDir, "test-report-history-flaky.json")
+
+		ctx := freshContext(nil, strings.NewReader(goTestEvents("pkg", "TestFlaky:pass")))
+		err = execute(ctx, []string{"-history", dir, "-maxFlaky", "0", "-output", output})
+		require.Equal(t, exitTestFailure, exitCode(err))
+
+		report, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+		require.Equal(t, 1, report.Results.Summary.Flaky)
+		require.True(t, report.Results.Tests[0].Flaky)
+		extra := reporter.ResultExtra(report.Results.Tests[0])
+		require.NotNil(t, extra)
+		require.NotNil(t, extra.History)
+		require.Equal(t, 2, extra.History.Flips)
+
+		runs, err := store.Runs(0)
+		require.NoError(t, err)
+		require.Len(t, runs, 3)
+		require.False(t, runs[2].Tests[0].Flaky, "the run is recorded as it was before the history marked it as flaky")
+	})
+
+	t.Run("should evaluate the flaky tests of a report written in version 0 as passed", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "specVersion0")
+		store, err := history.Open(dir)
+		require.NoError(t, err)
+		for _, status := range []ctrf.TestStatus{ctrf.TestPassed, ctrf.TestFailed} {
+			report := ctrf.NewReport("gotest", nil)
+			report.Results.Tests = []*ctrf.TestResult{{Suite: []string{"pkg"}, Name: "TestFlaky", Status: status}}
+			require.NoError(t, store.Ingest(report))
+		}
+		output := filepath.Join(tempDir, "test-report-history-v0.json")
+
+		ctx := freshContext(nil, strings.NewReader(goTestEvents("pkg", "TestFlaky:pass")))
+		err = execute(ctx, []string{"-history", dir, "-specVersion", ctrf.SpecVersion0, "-minPassRate", "100", "-output", output})
+		require.NoError(t, err)
+
+		report, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+		require.Equal(t, ctrf.SpecVersionCTRF, report.SpecVersion, "the report is upgraded when it is read")
+		require.Equal(t, 1, report.Results.Summary.Tests)
+		require.Equal(t, 1, report.Results.Summary.Passed)
+		require.Equal(t, 1, report.Results.Summary.Flaky)
+	})
+
+	t.Run("should not record a run which report can't be written", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "unwritten")
+
+		ctx := freshContext(nil, strings.NewReader(goTestEvents("pkg", "TestA:pass")))
+		err := execute(ctx, []string{"-history", dir, "-output", tempDir}) // a directory, which can't be written as a file
+		require.Equal(t, exitReporterError, exitCode(err))
+
+		store, err := history.Open(dir)
+		require.NoError(t, err)
+		runs, err := store.Runs(0)
+		require.NoError(t, err)
+		require.Empty(t, runs)
+	})
+
+	t.Run("should not create a missing history directory", func(t *testing.T) {
+		dir := filepath.Join(tempDir, "typo")
+
+		err := execute(freshContext(nil, nil), []string{"history", "-history", dir})
+		require.Equal(t, exitReporterError, exitCode(err))
+		require.ErrorContains(t, err, "error opening history store")
+		require.NoDirExists(t, dir)
+	})
+
+	t.Run("should require a history directory", func(t *testing.T) {
+		err := execute(freshContext(nil, nil), []string{"history"})
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.ErrorContains(t, err, "the -history directory is required")
+	})
+}
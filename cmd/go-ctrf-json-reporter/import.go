@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/junit"
+)
+
+// importFormats maps the formats that convert into CTRF to their reader.
+var importFormats = map[string]func(cmd *commandContext, filenames []string) (*ctrf.Report, error){
+	"junit": importJUnit,
+}
+
+// executeImport converts reports of other formats into a CTRF report.
+func executeImport(cmd *commandContext, args []string) error {
+	var outputFile, toolName, specVersion string
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter import junit [flags] [report.xml...]", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&outputFile, "output", "ctrf-report.json", "The output file for the test results")
+	fs.StringVar(&toolName, "toolName", "", "The name of the tool that ran the tests, instead of the name of the format.")
+	fs.StringVar(&specVersion, "specVersion", ctrf.SpecVersionCTRF, "The version of the CTRF specification of the report: "+strings.Join(ctrf.SpecVersions, ", ")+".")
+
+	if len(args) == 0 {
+		return &usageError{err: errors.New("expected a format to import"), usage: usage(fs)}
+	}
+	read, ok := importFormats[args[0]]
+	if !ok {
+		return &usageError{err: fmt.Errorf("unsupported import format %q", args[0]), usage: usage(fs)}
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+
+	report, err := read(cmd, fs.Args())
+	if err != nil {
+		return err
+	}
+	if toolName != "" {
+		report.Results.Tool.Name = toolName
+	}
+	if err := report.ConvertTo(specVersion); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+
+	return writeCTRFFile(cmd, outputFile, report)
+}
+
+// importJUnit converts JUnit XML files, or the standard input without files.
+func importJUnit(cmd *commandContext, filenames []string) (*ctrf.Report, error) {
+	if len(filenames) == 0 {
+		return junit.Read(cmd.reader)
+	}
+
+	return junit.ReadFiles(filenames...)
+}
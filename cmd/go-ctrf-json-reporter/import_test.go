@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteImport(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	t.Run("should convert junit files", func(t *testing.T) {
+		outputFile := filepath.Join(tempDir, "junit.json")
+		err := execute(freshContext(nil, nil), []string{
+			"import", "junit", "-output", outputFile, "-toolName", "maven",
+			filepath.Join("..", "..", "ctrf", "junit", "testdata", "surefire.xml"),
+			filepath.Join("..", "..", "ctrf", "junit", "testdata", "jest.xml"),
+		})
+		require.NoError(t, err)
+
+		report, err := ctrf.ReadFile(outputFile)
+		require.NoError(t, err)
+		require.Equal(t, "maven", report.Results.Tool.Name)
+		require.Equal(t, 7, report.Results.Summary.Tests)
+	})
+
+	t.Run("should convert the standard input", func(t *testing.T) {
+		outputFile := filepath.Join(tempDir, "stdin.json")
+		input := strings.NewReader(`<testsuite name="s"><testcase name="t"/></testsuite>`)
+
+		var stdout bytes.Buffer
+		require.NoError(t, execute(freshContext(&stdout, input), []string{"import", "junit", "-output", outputFile}))
+		require.Equal(t, "go-ctrf-json-reporter: successfully written ctrf json to "+outputFile+"\n", stdout.String())
+
+		report, err := ctrf.ReadFile(outputFile)
+		require.NoError(t, err)
+		require.Equal(t, "junit", report.Results.Tool.Name)
+		require.Equal(t, 1, report.Results.Summary.Passed)
+	})
+
+	t.Run("should require a supported format", func(t *testing.T) {
+		var usageErr *usageError
+		err := execute(freshContext(nil, nil), []string{"import", "xunit"})
+		require.True(t, errors.As(err, &usageErr))
+		require.EqualError(t, err, `unsupported import format "xunit"`)
+
+		err = execute(freshContext(nil, nil), []string{"import"})
+		require.True(t, errors.As(err, &usageErr))
+	})
+}
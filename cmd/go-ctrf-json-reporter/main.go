@@ -7,33 +7,57 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
 	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
 )
 
 // commandContext holds the global context of the command.
 //
-// For now, this boils down to just CLI flags and default stdin/stdout.
+// For now, this boils down to just CLI flags and default stdin/stdout/stderr.
 type commandContext struct {
 	commandFlags
 
-	reader io.Reader
-	writer io.Writer // makes it easier to test execute() independently
+	reader    io.Reader
+	writer    io.Writer  // makes it easier to test execute() independently
+	errWriter io.Writer  // receives usage text and diagnostics
+	goTest    goTestFunc // runs go test in the run subcommand, defaults to the go command
 }
 
 // commandFlags stores parsed command line flags.
 type commandFlags struct {
-	outputFile  string
-	verbose     bool
-	quiet       bool
-	appName     string
-	appVersion  string
-	oSPlatform  string
-	oSRelease   string
-	oSVersion   string
-	buildName   string
-	buildNumber string
+	outputFile   string
+	format       string
+	specVersion  string
+	writeInvalid bool
+	verbose      bool
+	progress     bool
+	quiet        bool
+	appName      string
+	appVersion   string
+	oSPlatform   string
+	oSRelease    string
+	oSVersion    string
+	buildName    string
+	buildNumber  string
+	quarantine   string
+	codeOwners   string
+
+	coverProfile   string
+	coverageFiles  bool
+	attachmentsDir string
+
+	pushgateway    string
+	pushgatewayJob string
+
+	historyFlags
+	trendFlags
+	otlpFlags
+	exitPolicy
 }
 
 // NOTE(fredbi)
@@ -50,82 +74,325 @@ func main() {
 	var ctx commandContext
 	ctx.reader = os.Stdin
 	ctx.writer = os.Stdout
+	ctx.errWriter = os.Stderr
+
+	if err := execute(&ctx, os.Args[1:]); err != nil {
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			if errors.Is(err, flag.ErrHelp) {
+				fmt.Fprint(ctx.errWriter, usageErr.usage)
+				os.Exit(exitOK)
+			}
+
+			fmt.Fprintf(ctx.errWriter, "%v\n\n%s", err, usageErr.usage)
+			os.Exit(exitReporterError)
+		}
+
+		if !ctx.quiet {
+			log.Printf("%v", err)
+		}
+
+		os.Exit(exitCode(err))
+	}
+}
+
+// usageError is returned when the command line cannot be parsed.
+//
+// It carries the usage text of the (sub)command that failed to parse its arguments,
+// so the caller decides where and whether to print it.
+type usageError struct {
+	err   error
+	usage string
+}
+
+func (e *usageError) Error() string {
+	return e.err.Error()
+}
 
-	registerFlags(&ctx.commandFlags)
+func (e *usageError) Unwrap() error {
+	return e.err
+}
 
-	if err := execute(&ctx); err != nil {
-		if ctx.quiet {
-			os.Exit(1) // exit silently
+// subcommand is the entry point of a subcommand, which receives the arguments that follow its name.
+type subcommand func(cmd *commandContext, args []string) error
+
+// subcommands maps subcommand names to their entry point.
+//
+// When the first argument is not a known subcommand, the arguments are parsed as the flags
+// of the default command, which converts "go test -json" output into a CTRF report.
+var subcommands = map[string]subcommand{
+	"help":    executeHelp,
+	"history": executeHistory,
+	"import":  executeImport,
+	"merge":   executeMerge,
+	"owners":  executeOwners,
+	"run":     executeRun,
+	"split":   executeSplit,
+	"trend":   executeTrend,
+}
+
+// execute runs the command with the arguments provided, without the program name.
+//
+// Command line parsing never exits the process: parsing errors are returned as a *usageError.
+func execute(cmd *commandContext, args []string) error {
+	if len(args) > 0 {
+		if sub, ok := subcommands[args[0]]; ok {
+			return sub(cmd, args[1:])
 		}
+	}
+
+	flags := newFlagSet("go-ctrf-json-reporter", &cmd.commandFlags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if err := checkReportFlags(flags, &cmd.commandFlags); err != nil {
+		return err
+	}
 
-		log.Fatalf("%v", err)
+	return executeReport(cmd)
+}
+
+// checkReportFlags checks the consistency of the flags of the report, once parsed with fs.
+func checkReportFlags(fs *flag.FlagSet, flags *commandFlags) error {
+	if !isFormat(flags.format) {
+		return &usageError{err: fmt.Errorf("unsupported -format %q", flags.format), usage: usage(fs)}
+	}
+
+	if !ctrf.IsSpecVersion(flags.specVersion) {
+		return &usageError{err: fmt.Errorf("unsupported -specVersion %q", flags.specVersion), usage: usage(fs)}
+	}
+
+	if flags.trend && flags.trendBaseline == "" && flags.historyDir == "" {
+		return &usageError{err: errNoBaseline, usage: usage(fs)}
+	}
+	if flags.minCoverage > 0 && flags.coverProfile == "" {
+		return &usageError{err: errors.New("-minCoverage requires a -coverprofile"), usage: usage(fs)}
 	}
+
+	return nil
+}
+
+func executeHelp(_ *commandContext, _ []string) error {
+	flags := newFlagSet("go-ctrf-json-reporter", &commandFlags{})
+
+	return &usageError{err: flag.ErrHelp, usage: usage(flags)}
 }
 
-func execute(cmd *commandContext) error {
+func executeReport(cmd *commandContext) error {
 	env := ctrfEnvFromFlags(cmd)
 	effectiveVerbose := cmd.verbose && !cmd.quiet
 
-	report, err := reporter.ParseTestResults(cmd.reader, effectiveVerbose, env)
+	opts := reporter.ParseOptions{Verbose: effectiveVerbose, Environment: env}
+	progress := startProgress(cmd)
+	if progress != nil {
+		opts.OnEvent = progress.Event
+	}
+
+	report, err := reporter.ParseTestResultsWith(cmd.reader, opts)
+	if progress != nil {
+		progress.Close()
+	}
 	if err != nil {
 		return fmt.Errorf("error parsing test results: %w", err)
 	}
 
-	err = reporter.WriteReportToFile(cmd.outputFile, report)
+	var historyRun *history.Run
+	if cmd.historyDir != "" {
+		if historyRun, err = applyHistory(cmd.historyFlags, report); err != nil {
+			return err
+		}
+	}
+
+	if cmd.trend {
+		analysis, err := analyzeTrend(cmd.trendFlags, cmd.historyFlags, report)
+		if err != nil {
+			return err
+		}
+
+		if err = reporter.UpdateExtra(report, func(extra *reporter.Extra) { extra.DurationTrend = analysis }); err != nil {
+			return err
+		}
+	}
+
+	if cmd.codeOwners != "" {
+		codeOwners, err := loadCodeOwners(cmd.codeOwners)
+		if err != nil {
+			return err
+		}
+
+		if _, err = codeOwners.Apply(report); err != nil {
+			return err
+		}
+	}
+
+	if cmd.coverProfile != "" {
+		coverage, err := reporter.LoadCoverProfile(cmd.coverProfile)
+		if err != nil {
+			return err
+		}
+
+		if err = coverage.Apply(report, cmd.coverageFiles); err != nil {
+			return err
+		}
+	}
+
+	if cmd.quarantine != "" {
+		quarantine, err := reporter.LoadQuarantine(cmd.quarantine)
+		if err != nil {
+			return err
+		}
+
+		result, err := quarantine.Apply(report, time.Now())
+		if err != nil {
+			return err
+		}
+		if !cmd.quiet {
+			for _, warning := range result.Warnings {
+				fmt.Fprintln(cmd.errWriter, "warning:", warning)
+			}
+		}
+	}
+
+	if cmd.attachmentsDir != "" {
+		if err = copyAttachments(cmd, report); err != nil {
+			return err
+		}
+	}
+
+	err = writeReport(cmd, report)
 	if err != nil {
 		return fmt.Errorf("error writing the report to file: %w", err)
 	}
 
-	if !cmd.verbose && !cmd.quiet { // when verbose is enabled, output is already written during parsing
-		buildOutput := reporter.GetBuildOutput()
-		fmt.Fprint(cmd.writer, buildOutput)
+	if historyRun != nil {
+		if err = ingestHistory(cmd.historyFlags, historyRun); err != nil {
+			return err
+		}
 	}
 
-	var buildFailed bool
-	if report.Results.Extra != nil {
-		extraMap, isMap := report.Results.Extra.(map[string]any)
-		if !isMap {
-			err = fmt.Errorf("expected a map, but got %T instead", report.Results.Extra)
-			return fmt.Errorf("error extracting report results: %w", err)
+	if cmd.pushgateway != "" {
+		if err = pushMetrics(cmd, report); err != nil {
+			return err
 		}
-		if _, ok := extraMap["buildFail"]; ok {
-			buildFailed = true
-		}
-		if _, ok := extraMap["FailedBuild"]; ok {
-			buildFailed = true
+	}
+
+	if cmd.otlpFile != "" || cmd.otlpEndpoint != "" {
+		if err = exportTraces(cmd.otlpFlags, report); err != nil {
+			return err
 		}
 	}
 
-	if report.Results.Summary.Failed > 0 {
-		buildFailed = true
+	if !cmd.verbose && !cmd.quiet { // when verbose is enabled, output is already written during parsing
+		buildOutput := reporter.GetBuildOutput()
+		fmt.Fprint(cmd.writer, buildOutput)
 	}
 
-	if buildFailed {
-		return errors.New("build failed")
+	return cmd.exitPolicy.evaluate(report)
+}
+
+// newFlagSet builds the flag set of the default command, bound to flags.
+//
+// The flag set never prints anything nor exits: errors are handled by parseFlags.
+func newFlagSet(name string, flags *commandFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.BoolVar(&flags.verbose, "verbose", false, "Enable verbose output")
+	fs.BoolVar(&flags.progress, "progress", false, "Show the progress of the tests on the standard error while they run, redrawn in place on a terminal.")
+	fs.BoolVar(&flags.quiet, "quiet", false, "Disable all log output")
+	fs.StringVar(&flags.outputFile, "output", "ctrf-report.json", "The output file for the test results")
+	fs.StringVar(&flags.format, "format", formatCTRF, "The format of the output file: "+strings.Join(formats, ", ")+".")
+	fs.StringVar(&flags.specVersion, "specVersion", ctrf.SpecVersionCTRF, "The version of the CTRF specification of the report: "+strings.Join(ctrf.SpecVersions, ", ")+".")
+	fs.BoolVar(&flags.writeInvalid, "writeInvalid", false, "Write the report even when it is invalid, with its problems printed as warnings and recorded in its extra field.")
+
+	fs.StringVar(&flags.appName, "appName", "", "The name of the application being tested.")
+	fs.StringVar(&flags.appVersion, "appVersion", "", "The version of the application being tested.")
+	fs.StringVar(&flags.oSPlatform, "osPlatform", "", "The operating system platform (e.g., Windows, Linux).")
+	fs.StringVar(&flags.oSRelease, "osRelease", "", "The release version of the operating system.")
+	fs.StringVar(&flags.oSVersion, "osVersion", "", "The version number of the operating system.")
+	fs.StringVar(&flags.buildName, "buildName", "", "The name of the build (e.g., feature branch name).")
+	fs.StringVar(&flags.buildNumber, "buildNumber", "", "The build number or identifier.")
+
+	fs.StringVar(&flags.attachmentsDir, "attachmentsDir", "", "A directory to copy the files attached to the tests to, relative to the directory of the -output file.")
+	fs.StringVar(&flags.quarantine, "quarantine", "", "A YAML or JSON file listing quarantined tests, which may fail without failing the build.")
+	fs.StringVar(&flags.codeOwners, "codeowners", "", `A CODEOWNERS file to attribute tests to their owners. "auto" looks it up at its usual locations.`)
+
+	fs.StringVar(&flags.coverProfile, "coverprofile", "", "A cover profile, as written by go test -coverprofile, to add the statement coverage to the report.")
+	fs.BoolVar(&flags.coverageFiles, "coverageFiles", false, "Break the coverage of each package down by file.")
+
+	registerHistoryFlags(fs, &flags.historyFlags)
+	fs.BoolVar(&flags.trend, "trend", false, "Compare the durations of the tests with past runs, and report slow test regressions in the extra field of the results.")
+	registerTrendFlags(fs, &flags.trendFlags)
+	registerOTLPFlags(fs, &flags.otlpFlags)
+	fs.StringVar(&flags.pushgateway, "pushgateway", "", `A Pushgateway to push the metrics of the tests to, e.g. "http://localhost:9091".`)
+	fs.StringVar(&flags.pushgatewayJob, "pushgatewayJob", "go-ctrf-json-reporter", "The job to push the metrics as, to the -pushgateway.")
+
+	fs.BoolVar(&flags.failOnFailures, "failOnFailures", true, "Exit with code 1 when tests fail.")
+	fs.BoolVar(&flags.failOnBuildFailure, "failOnBuildFailure", true, "Exit with code 2 when packages fail to build.")
+	fs.IntVar(&flags.maxFlaky, "maxFlaky", -1, "Exit with code 1 when there are more flaky tests than this (-1 allows any number).")
+	fs.Float64Var(&flags.minPassRate, "minPassRate", 0, "Exit with code 1 when the percentage of passed tests among the tests that ran is below this.")
+	fs.Float64Var(&flags.minCoverage, "minCoverage", 0, "Exit with code 1 when the percentage of statements covered by the -coverprofile is below this.")
+	fs.Float64Var(&flags.minPackageCoverage, "minPackageCoverage", 0,
+		"Exit with code 1 when the percentage of statements covered in a package, as printed by go test -cover or from the -coverprofile, is below this.")
+
+	shorthand(fs, "v", "verbose")
+	shorthand(fs, "q", "quiet")
+	shorthand(fs, "o", "output")
+
+	return fs
+}
+
+// shorthand registers alias as another name for an already registered flag.
+//
+// Both names share the same value, so they can't disagree on their default.
+func shorthand(fs *flag.FlagSet, alias, name string) {
+	f := fs.Lookup(name)
+	fs.Var(f.Value, alias, f.Usage+" (shorthand)")
+}
+
+// parseFlags parses args with fs, and wraps any error into a *usageError.
+//
+// Positional arguments are not supported by the commands that use this helper.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+
+	if fs.NArg() > 0 {
+		return &usageError{err: fmt.Errorf("unexpected argument: %q", fs.Arg(0)), usage: usage(fs)}
 	}
 
 	return nil
 }
 
-func registerFlags(flags *commandFlags) {
-	flag.BoolVar(&flags.verbose, "verbose", false, "Enable verbose output")
-	flag.BoolVar(&flags.verbose, "v", false, "Enable verbose output (shorthand)")
-	flag.BoolVar(&flags.quiet, "quiet", false, "Disable all log output")
-	flag.BoolVar(&flags.quiet, "q", false, "Disable all log output (shorthand)")
+// usage renders the usage text of a flag set.
+func usage(fs *flag.FlagSet) string {
+	var b strings.Builder
 
-	flag.StringVar(&flags.outputFile, "output", "ctrf-report.json", "The output file for the test results")
-	flag.StringVar(&flags.outputFile, "o", "ctrf-report.json", "The output file for the test results (shorthand)")
+	fmt.Fprintf(&b, "Usage of %s:\n", fs.Name())
+	fs.SetOutput(&b)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
 
-	flag.StringVar(&flags.appName, "appName", "", "The name of the application being tested.")
-	flag.StringVar(&flags.appVersion, "appVersion", "", "The version of the application being tested.")
-	flag.StringVar(&flags.oSPlatform, "osPlatform", "", "The operating system platform (e.g., Windows, Linux).")
-	flag.StringVar(&flags.oSRelease, "osRelease", "", "The release version of the operating system.")
-	flag.StringVar(&flags.oSVersion, "osVersion", "", "The version number of the operating system.")
-	flag.StringVar(&flags.buildName, "buildName", "", "The name of the build (e.g., feature branch name).")
-	flag.StringVar(&flags.buildNumber, "buildNumber", "", "The build number or identifier.")
+	return b.String()
+}
+
+// copyAttachments copies the files attached to the tests to the -attachmentsDir, next to the report.
+func copyAttachments(cmd *commandContext, report *ctrf.Report) error {
+	reportDir := filepath.Dir(cmd.outputFile)
+	dir := cmd.attachmentsDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(reportDir, dir)
+	}
+
+	warnings, err := reporter.CopyAttachments(report, dir, reportDir)
+	if !cmd.quiet {
+		for _, warning := range warnings {
+			fmt.Fprintln(cmd.errWriter, "warning:", warning)
+		}
+	}
 
-	// parsing errors result in os.Exit(1). Perhaps we should call the flagset version and capture the error instead.
-	flag.Parse()
+	return err
 }
 
 func ctrfEnvFromFlags(cmd *commandContext) *ctrf.Environment {
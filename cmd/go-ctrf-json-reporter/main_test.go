@@ -3,11 +3,14 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,7 +22,7 @@ func TestExecute(t *testing.T) {
 		t.Run("should error because no output file is provided", func(t *testing.T) {
 			ctx := freshContext(nil, nil)
 
-			err := execute(ctx)
+			err := execute(ctx, []string{"-output", ""})
 			require.Error(t, err)
 			require.ErrorContains(t, err, "no such file")
 		})
@@ -27,9 +30,8 @@ func TestExecute(t *testing.T) {
 		t.Run("should error because no report data is provided", func(t *testing.T) {
 			ctx := freshContext(nil, nil)
 			output := filepath.Join(tempDir, "test-report-ko.json")
-			ctx.outputFile = output
 
-			err := execute(ctx)
+			err := execute(ctx, []string{"-output", output})
 			require.Error(t, err)
 			require.ErrorContains(t, err, "report is invalid")
 		})
@@ -44,9 +46,8 @@ func TestExecute(t *testing.T) {
 			var stdout bytes.Buffer
 			ctx := freshContext(&stdout, fixture)
 			output := filepath.Join(tempDir, "test-report-ok.json")
-			ctx.outputFile = output
 
-			err = execute(ctx)
+			err = execute(ctx, []string{"-o", output})
 			require.NoError(t, err)
 			require.FileExists(t, output)
 
@@ -75,10 +76,8 @@ func TestExecute(t *testing.T) {
 			output := filepath.Join(tempDir, "test-report-app.json")
 
 			ctx := freshContext(nil, fixture)
-			ctx.appName = "my-app"
-			ctx.outputFile = output
 
-			err = execute(ctx)
+			err = execute(ctx, []string{"-appName", "my-app", "-output", output})
 			require.NoError(t, err)
 			require.FileExists(t, output)
 
@@ -115,11 +114,10 @@ func TestExecute(t *testing.T) {
 			output := filepath.Join(tempDir, "test-report-verbose.json")
 
 			ctx := freshContext(nil, fixture)
-			ctx.verbose = true
-			ctx.outputFile = output
 
-			err = execute(ctx)
+			err = execute(ctx, []string{"-v", "-output", output})
 			require.NoError(t, err)
+			require.True(t, ctx.verbose)
 			require.FileExists(t, output)
 
 			t.Run("report file should be valid JSON, without environment", func(t *testing.T) {
@@ -139,6 +137,82 @@ func TestExecute(t *testing.T) {
 	})
 }
 
+func TestExecuteUsage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should return a usage error on unknown flag", func(t *testing.T) {
+		ctx := freshContext(nil, nil)
+
+		err := execute(ctx, []string{"-unknown"})
+		require.Error(t, err)
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.ErrorContains(t, err, "flag provided but not defined: -unknown")
+		require.Contains(t, usageErr.usage, "-output string")
+	})
+
+	t.Run("should return a usage error on unexpected argument", func(t *testing.T) {
+		ctx := freshContext(nil, nil)
+
+		err := execute(ctx, []string{"-q", "report.json"})
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.ErrorContains(t, err, `unexpected argument: "report.json"`)
+	})
+
+	t.Run("should return help as a usage error", func(t *testing.T) {
+		for _, args := range [][]string{{"-h"}, {"help"}} {
+			ctx := freshContext(nil, nil)
+
+			err := execute(ctx, args)
+			require.ErrorIs(t, err, flag.ErrHelp)
+
+			var usageErr *usageError
+			require.ErrorAs(t, err, &usageErr)
+			require.Contains(t, usageErr.usage, "Usage of go-ctrf-json-reporter:")
+		}
+	})
+
+	t.Run("shorthand flags should share their value and default", func(t *testing.T) {
+		var flags commandFlags
+		fs := newFlagSet("test", &flags)
+		require.Equal(t, fs.Lookup("output").DefValue, fs.Lookup("o").DefValue)
+
+		require.NoError(t, parseFlags(fs, []string{"-o", "custom.json", "-q"}))
+		require.Equal(t, "custom.json", flags.outputFile)
+		require.True(t, flags.quiet)
+	})
+}
+
+func TestExecuteAttachments(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	logFile := filepath.Join(tempDir, "server.log")
+	require.NoError(t, os.WriteFile(logFile, []byte("panic: boom\n"), 0o600))
+	output, err := json.Marshal("    main_test.go:12: ctrf:attach server=" + logFile + "\n")
+	require.NoError(t, err)
+
+	input := `{"Time":"2025-03-02T01:08:01.8+01:00","Action":"run","Package":"example.com/pkg","Test":"TestServer"}
+{"Time":"2025-03-02T01:08:01.8+01:00","Action":"output","Package":"example.com/pkg","Test":"TestServer","Output":` + string(output) + `}
+{"Time":"2025-03-02T01:08:01.9+01:00","Action":"pass","Package":"example.com/pkg","Test":"TestServer","Elapsed":0.1}`
+	outputFile := filepath.Join(tempDir, "report", "ctrf-report.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(outputFile), 0o755))
+
+	err = execute(freshContext(nil, strings.NewReader(input)), []string{"-output", outputFile, "-attachmentsDir", "artifacts"})
+	require.NoError(t, err)
+
+	report, err := ctrf.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Len(t, report.Results.Tests, 1)
+	require.Equal(t, []ctrf.Attachment{{
+		Name: "server", ContentType: "text/plain", Path: "artifacts/example.com_pkg.TestServer/server.log",
+	}}, report.Results.Tests[0].Attachments)
+	require.FileExists(t, filepath.Join(tempDir, "report", "artifacts", "example.com_pkg.TestServer", "server.log"))
+}
+
 func freshContext(writer io.Writer, reader io.Reader) *commandContext {
 	if reader == nil {
 		reader = os.Stdin
@@ -148,7 +222,8 @@ func freshContext(writer io.Writer, reader io.Reader) *commandContext {
 	}
 
 	return &commandContext{
-		writer: writer,
-		reader: reader,
+		writer:    writer,
+		reader:    reader,
+		errWriter: new(bytes.Buffer),
 	}
 }
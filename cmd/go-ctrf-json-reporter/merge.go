@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// executeMerge combines CTRF reports, e.g. the reports of the packages written by gotest.Run, into a single report.
+//
+// The quarantined failures recorded by the reporter in the reports are added up.
+func executeMerge(cmd *commandContext, args []string) error {
+	var outputFile string
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter merge [flags] <report.json|glob>...", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&outputFile, "output", "ctrf-report.json", "The output file for the merged report")
+
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+	if fs.NArg() == 0 {
+		return &usageError{err: errors.New("expected reports to merge"), usage: usage(fs)}
+	}
+
+	var (
+		reports     []*ctrf.Report
+		quarantined int
+	)
+	for _, pattern := range fs.Args() {
+		filenames, err := filepath.Glob(pattern)
+		if err != nil {
+			return &usageError{err: err, usage: usage(fs)}
+		}
+		if len(filenames) == 0 {
+			return fmt.Errorf("no report matches %s", pattern)
+		}
+
+		for _, filename := range filenames {
+			if filepath.Clean(filename) == filepath.Clean(outputFile) {
+				continue
+			}
+
+			report, err := ctrf.ReadFile(filename)
+			if err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+			reports = append(reports, report)
+			quarantined += reporter.QuarantinedFailures(report)
+		}
+	}
+
+	merged := ctrf.Merge(reports...)
+	if quarantined > 0 {
+		if err := reporter.UpdateExtra(merged, func(extra *reporter.Extra) { extra.Quarantined = quarantined }); err != nil {
+			return err
+		}
+	}
+
+	return writeCTRFFile(cmd, outputFile, merged)
+}
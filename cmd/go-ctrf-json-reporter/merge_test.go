@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteMerge(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	for i, summary := range []ctrf.Summary{{Tests: 1, Passed: 1}, {Tests: 1, Failed: 1}, {Tests: 1, Failed: 1}} {
+		summary := summary
+		report := ctrf.NewReport("gotest", nil)
+		report.Results.Summary = &summary
+		status := ctrf.TestPassed
+		if summary.Failed > 0 {
+			status = ctrf.TestFailed
+			// The failures are quarantined.
+			require.NoError(t, reporter.UpdateExtra(report, func(extra *reporter.Extra) { extra.Quarantined = 1 }))
+		}
+		report.Results.Tests = []*ctrf.TestResult{{Name: "Test", Status: status}}
+		require.NoError(t, report.WriteFile(filepath.Join(tempDir, fmt.Sprintf("report-%d.json", i))))
+	}
+	outputFile := filepath.Join(tempDir, "merged.json")
+
+	var stdout bytes.Buffer
+	err := execute(freshContext(&stdout, nil), []string{"merge", "-output", outputFile, filepath.Join(tempDir, "*.json")})
+	require.NoError(t, err)
+	require.Equal(t, "go-ctrf-json-reporter: successfully written ctrf json to "+outputFile+"\n", stdout.String())
+
+	merged, err := ctrf.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Equal(t, 3, merged.Results.Summary.Tests)
+	require.Equal(t, 2, merged.Results.Summary.Failed)
+	require.Equal(t, 2, reporter.QuarantinedFailures(merged), "the quarantined failures are added up")
+
+	// Merging again skips the merged report.
+	require.NoError(t, execute(freshContext(nil, nil), []string{"merge", "-output", outputFile, filepath.Join(tempDir, "*.json")}))
+	merged, err = ctrf.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Equal(t, 3, merged.Results.Summary.Tests)
+
+	// The flaky tests of the reports of version 0 are counted apart.
+	legacyFile := filepath.Join(tempDir, "legacy", "report.json")
+	legacy := ctrf.NewReport("gotest", nil)
+	legacy.Results.Summary = &ctrf.Summary{Tests: 1, Passed: 1, Flaky: 1}
+	legacy.Results.Tests = []*ctrf.TestResult{{Name: "TestFlaky", Status: ctrf.TestPassed, Flaky: true}}
+	require.NoError(t, legacy.ConvertTo(ctrf.SpecVersion0))
+	require.NoError(t, os.MkdirAll(filepath.Dir(legacyFile), 0o755))
+	require.NoError(t, legacy.WriteFile(legacyFile))
+	require.NoError(t, execute(freshContext(nil, nil), []string{"merge", "-output", outputFile, legacyFile, filepath.Join(tempDir, "report-0.json")}))
+	merged, err = ctrf.ReadFile(outputFile)
+	require.NoError(t, err)
+	require.Equal(t, 2, merged.Results.Summary.Passed)
+	require.Equal(t, 1, merged.Results.Summary.Flaky)
+
+	err = execute(freshContext(nil, nil), []string{"merge", filepath.Join(tempDir, "*.xml")})
+	require.ErrorContains(t, err, "no report matches")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/otlp"
+)
+
+// otlpTimeout bounds the time to send traces to the OTLP endpoint.
+const otlpTimeout = 30 * time.Second
+
+// otlpFlags stores the flags of the OpenTelemetry export.
+type otlpFlags struct {
+	otlpFile     string
+	otlpEndpoint string
+	otlpHeaders  string
+}
+
+func registerOTLPFlags(fs *flag.FlagSet, flags *otlpFlags) {
+	fs.StringVar(&flags.otlpFile, "otlpFile", "", "A file to write the test run to, as OpenTelemetry traces in the OTLP/JSON format.")
+	fs.StringVar(&flags.otlpEndpoint, "otlpEndpoint", "", `An OTLP/HTTP endpoint to send the test run to, as OpenTelemetry traces, e.g. "http://localhost:4318".`)
+	fs.StringVar(&flags.otlpHeaders, "otlpHeaders", "", `Headers to send to the -otlpEndpoint, e.g. "api-key=secret,tenant=team-a".`)
+}
+
+// exportTraces writes the report as traces to the -otlpFile, and sends them to the -otlpEndpoint.
+func exportTraces(flags otlpFlags, report *ctrf.Report) error {
+	traces := otlp.Export(report)
+
+	if flags.otlpFile != "" {
+		if err := traces.WriteFile(flags.otlpFile); err != nil {
+			return err
+		}
+	}
+
+	if flags.otlpEndpoint != "" {
+		headers, err := otlp.ParseHeaders(flags.otlpHeaders)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+		defer cancel()
+
+		if err := traces.Send(ctx, http.DefaultClient, flags.otlpEndpoint, headers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteOTLP(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	var received int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" && r.Header.Get("Authorization") == "Bearer token" {
+			atomic.AddInt32(&received, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+	require.NoError(t, err)
+	defer func() {
+		_ = fixture.Close()
+	}()
+	tracesFile := filepath.Join(tempDir, "traces.json")
+
+	err = execute(freshContext(nil, fixture), []string{
+		"-output", filepath.Join(tempDir, "test-report-otlp.json"),
+		"-otlpFile", tracesFile,
+		"-otlpEndpoint", collector.URL,
+		"-otlpHeaders", "Authorization=Bearer%20token",
+	})
+	require.NoError(t, err)
+
+	require.FileExists(t, tracesFile)
+	require.Equal(t, int32(1), atomic.LoadInt32(&received))
+}
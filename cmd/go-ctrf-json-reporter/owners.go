@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// codeOwnersAuto looks up the CODEOWNERS file at its usual locations.
+const codeOwnersAuto = "auto"
+
+// executeOwners prints the failures of a CTRF report, grouped by code owner.
+func executeOwners(cmd *commandContext, args []string) error {
+	var codeOwnersFile string
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter owners [flags] <report.json>", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&codeOwnersFile, "codeowners", codeOwnersAuto, `The CODEOWNERS file. "auto" looks it up at its usual locations.`)
+
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+	if fs.NArg() != 1 {
+		return &usageError{err: fmt.Errorf("expected a single report file, got %d argument(s)", fs.NArg()), usage: usage(fs)}
+	}
+
+	report, err := ctrf.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	codeOwners, err := loadCodeOwners(codeOwnersFile)
+	if err != nil {
+		return err
+	}
+
+	summary, err := codeOwners.Apply(report)
+	if err != nil {
+		return err
+	}
+
+	return reporter.WriteOwnersSummary(cmd.writer, summary)
+}
+
+// loadCodeOwners loads a CODEOWNERS file, or looks it up from the current directory with codeOwnersAuto.
+func loadCodeOwners(filename string) (*reporter.CodeOwners, error) {
+	if filename == codeOwnersAuto {
+		found, err := reporter.FindCodeOwners(".")
+		if err != nil {
+			return nil, err
+		}
+		filename = found
+	}
+
+	return reporter.LoadCodeOwners(filename)
+}
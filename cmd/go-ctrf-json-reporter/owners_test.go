@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteOwners(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	codeOwnersFile := filepath.Join(tempDir, "CODEOWNERS")
+	require.NoError(t, os.WriteFile(codeOwnersFile, []byte("/pkg/ @org/pkg\n"), 0o600))
+
+	report := ctrf.NewReport("gotest", nil)
+	report.Results.Summary = &ctrf.Summary{Tests: 2, Passed: 1, Failed: 1}
+	report.Results.Tests = []*ctrf.TestResult{
+		{Name: "TestFail", Status: ctrf.TestFailed, Suite: []string{"example.com/pkg"}, Filepath: filepath.Join(tempDir, "pkg", "pkg_test.go")},
+		{Name: "TestPass", Status: ctrf.TestPassed, Suite: []string{"example.com/pkg"}, Filepath: filepath.Join(tempDir, "pkg", "pkg_test.go")},
+	}
+	reportFile := filepath.Join(tempDir, "report.json")
+	require.NoError(t, report.WriteFile(reportFile))
+
+	t.Run("should print failures by owner", func(t *testing.T) {
+		var stdout bytes.Buffer
+		ctx := freshContext(&stdout, nil)
+
+		require.NoError(t, execute(ctx, []string{"owners", "-codeowners", codeOwnersFile, reportFile}))
+		require.Equal(t, "@org/pkg: 1 failed test(s)\n  example.com/pkg.TestFail\n", stdout.String())
+	})
+
+	t.Run("should require a report file", func(t *testing.T) {
+		err := execute(freshContext(nil, nil), []string{"owners", "-codeowners", codeOwnersFile})
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.Contains(t, usageErr.usage, "-codeowners string")
+	})
+
+	t.Run("should attribute tests in the report", func(t *testing.T) {
+		fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+		require.NoError(t, err)
+		defer func() {
+			_ = fixture.Close()
+		}()
+		output := filepath.Join(tempDir, "test-report-owners.json")
+
+		err = execute(freshContext(nil, fixture), []string{"-codeowners", codeOwnersFile, "-output", output})
+		require.NoError(t, err)
+
+		written, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+		extra := reporter.ReportExtra(written)
+		require.NotNil(t, extra)
+		require.Empty(t, extra.Owners, "no test failed")
+	})
+}
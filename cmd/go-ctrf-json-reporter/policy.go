@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// Exit codes of the command.
+const (
+	exitOK            = 0
+	exitTestFailure   = 1 // the exit policy is not met by the test results
+	exitBuildFailure  = 2 // some packages failed to build
+	exitReporterError = 3 // the reporter itself failed, e.g. on bad usage or when the report can't be written
+)
+
+// exitPolicy decides whether a report should fail the command.
+type exitPolicy struct {
+	failOnFailures     bool    // fail when some tests (or packages) failed
+	failOnBuildFailure bool    // fail when some packages failed to build
+	maxFlaky           int     // fail when there are more flaky tests than this. A negative value disables the check
+	minPassRate        float64 // fail when the pass rate (in percent) is below this. Zero disables the check
+	minCoverage        float64 // fail when the statement coverage (in percent) is below this. Zero disables the check
+	minPackageCoverage float64 // fail when the statement coverage (in percent) of a package is below this. Zero disables the check
+}
+
+// policyError is returned when a report does not meet the exit policy.
+type policyError struct {
+	exitCode int
+	reasons  []string
+}
+
+func (e *policyError) Error() string {
+	if e.exitCode == exitBuildFailure {
+		return "build failed: " + strings.Join(e.reasons, ", ")
+	}
+
+	return "tests failed: " + strings.Join(e.reasons, ", ")
+}
+
+// evaluate checks the report against the policy.
+//
+// It returns a *policyError when the policy is not met, or nil.
+// Build failures take precedence over test failures when determining the exit code.
+func (p exitPolicy) evaluate(report *ctrf.Report) error {
+	summary := report.Results.Summary
+
+	if p.failOnBuildFailure {
+		if failed := buildFailures(report); failed > 0 {
+			return &policyError{
+				exitCode: exitBuildFailure,
+				reasons:  []string{fmt.Sprintf("%d package build failure(s)", failed)},
+			}
+		}
+	}
+
+	var reasons []string
+	if p.failOnFailures {
+		// quarantined tests may fail without failing the build, and build failures are not test failures
+		if failed := summary.Failed - reporter.QuarantinedFailures(report) - buildFailureResults(report); failed > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d failed test(s)", failed))
+		}
+		if failed := unexplainedPackageFailures(report); len(failed) > 0 {
+			reasons = append(reasons, fmt.Sprintf("package(s) failed outside of any test: %s", strings.Join(failed, ", ")))
+		}
+	}
+
+	if p.maxFlaky >= 0 && summary.Flaky > p.maxFlaky {
+		reasons = append(reasons, fmt.Sprintf("%d flaky test(s), more than the %d allowed", summary.Flaky, p.maxFlaky))
+	}
+
+	if p.minPassRate > 0 {
+		if rate := passRate(summary); rate < p.minPassRate {
+			reasons = append(reasons, fmt.Sprintf("pass rate %.1f%% is below %.1f%%", rate, p.minPassRate))
+		}
+	}
+
+	if p.minCoverage > 0 {
+		if coverage := reporter.ReportCoverage(report); coverage == nil {
+			reasons = append(reasons, "no coverage to check")
+		} else if coverage.Percent < p.minCoverage {
+			reasons = append(reasons, fmt.Sprintf("coverage %.1f%% is below %.1f%%", coverage.Percent, p.minCoverage))
+		}
+	}
+
+	if p.minPackageCoverage > 0 {
+		if coverages := reporter.PackageCoverages(report); coverages == nil {
+			reasons = append(reasons, "no package coverage to check")
+		} else if below := packagesBelow(coverages, p.minPackageCoverage); len(below) > 0 {
+			reasons = append(reasons, fmt.Sprintf("package coverage below %.1f%%: %s", p.minPackageCoverage, strings.Join(below, ", ")))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return &policyError{exitCode: exitTestFailure, reasons: reasons}
+	}
+
+	return nil
+}
+
+// passRate is the percentage of passed tests among tests that actually ran, i.e. not skipped nor pending.
+//
+// Flaky tests that eventually passed are counted as passed. When no test ran, the pass rate is 100%.
+func passRate(summary *ctrf.Summary) float64 {
+	passed := summary.Passed
+	ran := summary.Tests - summary.Skipped - summary.Pending
+	if ran <= 0 {
+		return 100
+	}
+
+	return 100 * float64(passed) / float64(ran)
+}
+
+// packagesBelow lists the packages which coverage is below the minimum, with their coverage, sorted by package.
+func packagesBelow(coverages map[string]float64, minimum float64) []string {
+	var below []string
+	for pkg, coverage := range coverages {
+		if coverage < minimum {
+			below = append(below, fmt.Sprintf("%s (%.1f%%)", pkg, coverage))
+		}
+	}
+	sort.Strings(below)
+
+	return below
+}
+
+// buildFailures counts the packages that failed because of a build failure, i.e. that did not build themselves
+// or which dependencies did not.
+//
+// Reports of Go versions that don't tell which build failed a package (before Go 1.24) count the build-fail events instead.
+func buildFailures(report *ctrf.Report) int {
+	extra := reporter.ReportExtra(report)
+	if extra == nil {
+		return 0
+	}
+
+	var failed int
+	for _, pkg := range extra.Packages {
+		if pkg.FailedBuild != "" {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return len(extra.BuildFailures)
+	}
+
+	return failed
+}
+
+// buildFailureResults counts the failed tests that stand for packages that did not build,
+// leaving out the quarantined ones, which are already counted as quarantined failures.
+func buildFailureResults(report *ctrf.Report) int {
+	var failed int
+	for _, test := range report.Results.Tests {
+		if test.Status != ctrf.TestFailed || test.RawStatus != reporter.ActionBuildFail {
+			continue
+		}
+		if extra := reporter.ResultExtra(test); extra != nil && extra.Quarantine != nil {
+			continue
+		}
+		failed++
+	}
+
+	return failed
+}
+
+// unexplainedPackageFailures lists packages that failed without any failed test,
+// e.g. after a panic in an init function or a non-zero exit code from TestMain.
+//
+// Quarantined tests explain the failure of their package as well.
+func unexplainedPackageFailures(report *ctrf.Report) []string {
+	extra := reporter.ReportExtra(report)
+	if extra == nil {
+		return nil
+	}
+
+	var failed []string
+	for _, pkg := range extra.Packages {
+		if pkg.Status == ctrf.TestFailed && !hasFailedTest(report, pkg.Package) {
+			failed = append(failed, pkg.Package)
+		}
+	}
+
+	return failed
+}
+
+func hasFailedTest(report *ctrf.Report, pkg string) bool {
+	for _, test := range report.Results.Tests {
+		if test.Status == ctrf.TestFailed && len(test.Suite) > 0 && test.Suite[0] == pkg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exitCode maps the error returned by execute to the exit code of the process.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var policyErr *policyError
+	if errors.As(err, &policyErr) {
+		return policyErr.exitCode
+	}
+
+	return exitReporterError
+}
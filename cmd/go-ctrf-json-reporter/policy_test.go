@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitPolicy(t *testing.T) {
+	t.Parallel()
+
+	defaultPolicy := exitPolicy{failOnFailures: true, failOnBuildFailure: true, maxFlaky: -1}
+
+	t.Run("should pass a flaky but recovered run", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 2, Passed: 2, Flaky: 1})
+		report.Results.Extra = map[string]any{reporter.ExtraKey: &reporter.Extra{
+			Packages: []*reporter.PackageResult{{Package: "pkg", Status: ctrf.TestPassed}},
+		}}
+
+		require.NoError(t, defaultPolicy.evaluate(report))
+	})
+
+	t.Run("should fail on failed tests", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 2, Passed: 1, Failed: 1})
+
+		err := defaultPolicy.evaluate(report)
+		require.Error(t, err)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.EqualError(t, err, "tests failed: 1 failed test(s)")
+
+		lenient := defaultPolicy
+		lenient.failOnFailures = false
+		require.NoError(t, lenient.evaluate(report))
+	})
+
+	t.Run("should not fail on quarantined failures", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 2, Passed: 1, Failed: 1})
+		report.Results.Tests = []*ctrf.TestResult{{Name: "TestFlaky", Status: ctrf.TestFailed, Suite: []string{"pkg"}}}
+		report.Results.Extra = map[string]any{reporter.ExtraKey: &reporter.Extra{
+			Packages:    []*reporter.PackageResult{{Package: "pkg", Status: ctrf.TestFailed}},
+			Quarantined: 1,
+		}}
+
+		require.NoError(t, defaultPolicy.evaluate(report))
+	})
+
+	t.Run("should fail on package failures outside of tests", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 1, Passed: 1})
+		report.Results.Extra = map[string]any{reporter.ExtraKey: &reporter.Extra{
+			Packages: []*reporter.PackageResult{
+				{Package: "pkg", Status: ctrf.TestPassed},
+				{Package: "broken", Status: ctrf.TestFailed},
+			},
+		}}
+
+		err := defaultPolicy.evaluate(report)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.ErrorContains(t, err, "package(s) failed outside of any test: broken")
+	})
+
+	t.Run("should fail on build failures first", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 1, Failed: 1})
+		// As read from a report file
+		report.Results.Extra = map[string]any{reporter.ExtraKey: map[string]any{
+			"buildFailures": []any{map[string]any{"Action": reporter.ActionBuildFail, "Package": "broken"}},
+		}}
+
+		err := defaultPolicy.evaluate(report)
+		require.Equal(t, exitBuildFailure, exitCode(err))
+		require.EqualError(t, err, "build failed: 1 package build failure(s)")
+
+		lenient := defaultPolicy
+		lenient.failOnBuildFailure = false
+		require.Equal(t, exitTestFailure, exitCode(lenient.evaluate(report)))
+	})
+
+	t.Run("should fail when too many tests are flaky", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 3, Passed: 3, Flaky: 2})
+
+		strict := defaultPolicy
+		strict.maxFlaky = 1
+		err := strict.evaluate(report)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.ErrorContains(t, err, "2 flaky test(s), more than the 1 allowed")
+
+		strict.maxFlaky = 2
+		require.NoError(t, strict.evaluate(report))
+	})
+
+	t.Run("should fail when the pass rate is too low", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 5, Passed: 3, Failed: 1, Skipped: 1, Flaky: 1})
+
+		lenient := defaultPolicy
+		lenient.failOnFailures = false
+		lenient.minPassRate = 80
+		err := lenient.evaluate(report)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.ErrorContains(t, err, "pass rate 75.0% is below 80.0%")
+
+		lenient.minPassRate = 75
+		require.NoError(t, lenient.evaluate(report))
+	})
+
+	t.Run("should fail when the coverage is too low", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 1, Passed: 1})
+
+		strict := defaultPolicy
+		strict.minCoverage = 80
+		require.EqualError(t, strict.evaluate(report), "tests failed: no coverage to check")
+
+		(&reporter.Coverage{Percent: 79.5}).Apply(report, false)
+		err := strict.evaluate(report)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.EqualError(t, err, "tests failed: coverage 79.5% is below 80.0%")
+
+		strict.minCoverage = 79.5
+		require.NoError(t, strict.evaluate(report))
+	})
+
+	t.Run("should fail when the coverage of a package is too low", func(t *testing.T) {
+		report := policyReport(ctrf.Summary{Tests: 1, Passed: 1})
+		report.Results.Extra = map[string]any{reporter.ExtraKey: &reporter.Extra{
+			PackageCoverage: map[string]float64{"pkg/a": 83.2, "pkg/b": 12.5, "pkg/c": 0},
+		}}
+
+		strict := defaultPolicy
+		strict.minPackageCoverage = 50
+		err := strict.evaluate(report)
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.EqualError(t, err, "tests failed: package coverage below 50.0%: pkg/b (12.5%), pkg/c (0.0%)")
+
+		strict.minPackageCoverage = 0
+		require.NoError(t, strict.evaluate(report))
+	})
+
+	t.Run("should map other errors to a reporter error", func(t *testing.T) {
+		require.Equal(t, exitOK, exitCode(nil))
+		require.Equal(t, exitReporterError, exitCode(errors.New("error writing the report to file")))
+		require.Equal(t, exitReporterError, exitCode(&usageError{err: errors.New("bad flag")}))
+	})
+}
+
+// buildFailureEvents are the events of go test (Go 1.24 or newer) for a package that does not build,
+// a package which dependency does not build, a package that builds and a test that failed.
+const buildFailureEvents = `{"ImportPath":"pkg/bad [pkg/bad.test]","Action":"build-output","Output":"bad/bad.go:4:9: syntax error\n"}
+{"ImportPath":"pkg/bad [pkg/bad.test]","Action":"build-fail"}
+{"Time":"2024-01-01T00:00:00Z","Action":"fail","Package":"pkg/bad","Elapsed":0,"FailedBuild":"pkg/bad [pkg/bad.test]"}
+{"ImportPath":"pkg/dep","Action":"build-output","Output":"dep/dep.go:3:14: undefined: undefined\n"}
+{"ImportPath":"pkg/dep","Action":"build-fail"}
+{"Time":"2024-01-01T00:00:00Z","Action":"fail","Package":"pkg/dep","Elapsed":0,"FailedBuild":"pkg/dep"}
+{"Time":"2024-01-01T00:00:00Z","Action":"fail","Package":"pkg/usesdep","Elapsed":0,"FailedBuild":"pkg/dep"}
+`
+
+func TestExecuteBuildFailure(t *testing.T) {
+	t.Parallel()
+
+	t.Run("should fail on the packages that failed to build", func(t *testing.T) {
+		ctx := freshContext(nil, strings.NewReader(buildFailureEvents+goTestEvents("pkg/good", "TestGood:pass")))
+		err := execute(ctx, []string{"-output", filepath.Join(t.TempDir(), "report.json")})
+		require.Equal(t, exitBuildFailure, exitCode(err))
+		require.EqualError(t, err, "build failed: 3 package build failure(s)")
+	})
+
+	t.Run("should not count build failures as test failures with -failOnBuildFailure=false", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "report.json")
+		ctx := freshContext(nil, strings.NewReader(buildFailureEvents+goTestEvents("pkg/good", "TestGood:pass")))
+		require.NoError(t, execute(ctx, []string{"-failOnBuildFailure=false", "-output", output}))
+
+		report, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Results.Summary.Failed, "the build failures are still reported as failed tests")
+	})
+
+	t.Run("should still fail on test failures with -failOnBuildFailure=false", func(t *testing.T) {
+		ctx := freshContext(nil, strings.NewReader(buildFailureEvents+goTestEvents("pkg/good", "TestGood:pass", "TestBad:fail")))
+		err := execute(ctx, []string{"-failOnBuildFailure=false", "-output", filepath.Join(t.TempDir(), "report.json")})
+		require.Equal(t, exitTestFailure, exitCode(err))
+		require.EqualError(t, err, "tests failed: 1 failed test(s)")
+	})
+}
+
+func policyReport(summary ctrf.Summary) *ctrf.Report {
+	report := ctrf.NewReport("gotest", nil)
+	report.Results.Summary = &summary
+	report.Results.Tests = []*ctrf.TestResult{}
+
+	return report
+}
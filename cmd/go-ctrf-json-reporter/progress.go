@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// startProgress starts showing the progress of the tests on the standard error, with -progress, or returns nil.
+//
+// The progress is redrawn in place on a terminal, unless the verbose output of the tests is written along with it.
+func startProgress(cmd *commandContext) *reporter.Progress {
+	if !cmd.progress || cmd.quiet {
+		return nil
+	}
+
+	progress := reporter.NewProgress(cmd.errWriter, isTerminal(cmd.errWriter) && !cmd.verbose)
+	progress.Start()
+
+	return progress
+}
+
+// isTerminal tells if w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// eventWriter decodes the events of go test -json written to it, line by line, for a progress.
+//
+// The lines that are not events are ignored.
+type eventWriter struct {
+	progress *reporter.Progress
+	partial  []byte
+}
+
+func (e *eventWriter) Write(p []byte) (int, error) {
+	e.partial = append(e.partial, p...)
+	for {
+		i := bytes.IndexByte(e.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		var event reporter.TestEvent
+		if err := json.Unmarshal(e.partial[:i], &event); err == nil {
+			e.progress.Event(event)
+		}
+		e.partial = e.partial[i+1:]
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteProgress(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	t.Run("should show the progress while parsing", func(t *testing.T) {
+		ctx := freshContext(nil, strings.NewReader(goTestEvents("pkg/a", "TestA:pass", "TestB:fail")))
+
+		err := execute(ctx, []string{"-progress", "-output", filepath.Join(tempDir, "test-report-progress.json")})
+		require.Equal(t, exitTestFailure, exitCode(err))
+
+		assert.Equal(t, "--- FAIL: pkg/a TestB (1.00s)\n1 passed, 1 failed, 0 skipped\n", ctx.errWriter.(fmt.Stringer).String())
+	})
+
+	t.Run("should show the progress of the runs once", func(t *testing.T) {
+		var calls []string
+		ctx := freshContext(nil, nil)
+		ctx.goTest = fakeGoTest(&calls, map[string][]string{
+			"./...":           {goTestEvents("pkg/a", "TestA:pass", "TestB:fail")},
+			"^(TestB)$ pkg/a": {goTestEvents("pkg/a", "TestB:pass")},
+		})
+
+		err := execute(ctx, []string{"run", "-progress", "-output", filepath.Join(tempDir, "test-report-run-progress.json")})
+		require.NoError(t, err)
+
+		assert.Equal(t, "--- FAIL: pkg/a TestB (1.00s)\n2 passed, 1 failed, 0 skipped\n", ctx.errWriter.(fmt.Stringer).String())
+	})
+
+	t.Run("should not show the progress when quiet", func(t *testing.T) {
+		ctx := freshContext(nil, strings.NewReader(goTestEvents("pkg/a", "TestA:fail")))
+
+		err := execute(ctx, []string{"-progress", "-quiet", "-output", filepath.Join(tempDir, "test-report-progress-quiet.json")})
+		require.Equal(t, exitTestFailure, exitCode(err))
+
+		assert.Empty(t, ctx.errWriter.(fmt.Stringer).String())
+	})
+}
+
+func TestEventWriter(t *testing.T) {
+	var b bytes.Buffer
+	progress := reporter.NewProgress(&b, false)
+	writer := &eventWriter{progress: progress}
+
+	events := goTestEvents("pkg/a", "TestA:pass", "TestB:skip") + "not an event\n"
+	for _, chunk := range []string{events[:10], events[10:100], events[100:]} {
+		n, err := writer.Write([]byte(chunk))
+		require.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	progress.Close()
+
+	assert.Equal(t, "1 passed, 0 failed, 1 skipped\n", b.String())
+	assert.False(t, isTerminal(&b))
+}
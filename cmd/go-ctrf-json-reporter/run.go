@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// goTestFunc runs "go test" with args, and writes its JSON output to stdout.
+//
+// A non-zero exit status because of failed tests is not an error: failures are reported in the output.
+type goTestFunc func(args []string, stdout io.Writer) error
+
+// rerunFlags stores the flags of the run subcommand.
+type rerunFlags struct {
+	packages              string
+	rerunFails            int
+	rerunFailsMaxFailures int
+}
+
+// executeRun runs "go test -json", reruns the tests that failed, then reports the outcome of all the attempts.
+//
+// The arguments that follow the flags of the reporter (usually after "--") are passed to go test.
+// Failed tests are rerun per package, with an anchored -run regular expression of their top-level test,
+// so their attempts end up in the retries of their result, and the tests that eventually pass are flaky.
+func executeRun(cmd *commandContext, args []string) error {
+	var flags rerunFlags
+
+	fs := newFlagSet("go-ctrf-json-reporter run [flags] [-- go test flags]", &cmd.commandFlags)
+	fs.StringVar(&flags.packages, "packages", "./...", "The packages to test, separated by spaces.")
+	fs.IntVar(&flags.rerunFails, "rerunFails", 2, "The number of times the failed tests are rerun.")
+	fs.IntVar(&flags.rerunFailsMaxFailures, "rerunFailsMaxFailures", 10, "Do not rerun any test when more tests than this failed in the first run.")
+
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+	if err := checkReportFlags(fs, &cmd.commandFlags); err != nil {
+		return err
+	}
+
+	goTest := cmd.goTest
+	if goTest == nil {
+		stderr := cmd.errWriter
+		if cmd.quiet {
+			stderr = io.Discard
+		}
+		goTest = execGoTest(stderr)
+	}
+
+	// The progress is shown while go test runs, rather than once its output is reported.
+	progress := startProgress(cmd)
+	if progress != nil {
+		goTest = withProgress(goTest, progress)
+	}
+
+	output, err := runAttempts(cmd, goTest, flags, fs.Args())
+	if progress != nil {
+		progress.Close()
+		cmd.progress = false
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.reader = output
+
+	return executeReport(cmd)
+}
+
+// runAttempts runs "go test -json", then reruns the tests that failed, and returns the output of all the attempts.
+func runAttempts(cmd *commandContext, goTest goTestFunc, flags rerunFlags, goTestFlags []string) (*bytes.Buffer, error) {
+	var output, attempt bytes.Buffer
+	if err := goTest(append(append([]string{"test", "-json"}, goTestFlags...), strings.Fields(flags.packages)...), &attempt); err != nil {
+		return nil, fmt.Errorf("error running go test: %w", err)
+	}
+
+	for rerun := 1; rerun <= flags.rerunFails; rerun++ {
+		failed, err := reporter.FailedTests(bytes.NewReader(attempt.Bytes()))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing test results: %w", err)
+		}
+		output.Write(attempt.Bytes())
+		attempt.Reset()
+
+		var failures int
+		packages := make([]string, 0, len(failed))
+		for pkg, tests := range failed {
+			packages = append(packages, pkg)
+			failures += len(tests)
+		}
+		sort.Strings(packages)
+
+		if failures == 0 {
+			break
+		}
+		if rerun == 1 && failures > flags.rerunFailsMaxFailures {
+			if !cmd.quiet {
+				fmt.Fprintf(cmd.errWriter, "warning: not rerunning failed tests: %d tests failed, more than -rerunFailsMaxFailures %d\n",
+					failures, flags.rerunFailsMaxFailures)
+			}
+
+			break
+		}
+
+		for _, pkg := range packages {
+			rerunArgs := append(append([]string{"test", "-json"}, goTestFlags...), "-run", runPattern(failed[pkg]), pkg)
+			if err := goTest(rerunArgs, &attempt); err != nil {
+				return nil, fmt.Errorf("error rerunning failed tests: %w", err)
+			}
+		}
+	}
+	output.Write(attempt.Bytes())
+
+	return &output, nil
+}
+
+// withProgress shows the progress of the tests that goTest runs.
+func withProgress(goTest goTestFunc, progress *reporter.Progress) goTestFunc {
+	return func(args []string, stdout io.Writer) error {
+		return goTest(args, io.MultiWriter(stdout, &eventWriter{progress: progress}))
+	}
+}
+
+// execGoTest runs the go command, with its standard error written to stderr.
+func execGoTest(stderr io.Writer) goTestFunc {
+	return func(args []string, stdout io.Writer) error {
+		goCmd := exec.Command("go", args...)
+		goCmd.Stdout = stdout
+		goCmd.Stderr = stderr
+
+		var exitErr *exec.ExitError
+		if err := goCmd.Run(); err != nil && !errors.As(err, &exitErr) {
+			return err
+		}
+
+		return nil
+	}
+}
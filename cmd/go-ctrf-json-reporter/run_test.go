@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteRun(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	t.Run("should rerun failed tests", func(t *testing.T) {
+		var calls []string
+		ctx := freshContext(nil, nil)
+		ctx.goTest = fakeGoTest(&calls, map[string][]string{
+			"./...":                       {goTestEvents("pkg/a", "TestFlaky:fail", "TestOK:pass", "TestSub/one:fail", "TestSub:fail") + goTestEvents("pkg/b", "TestB:fail")},
+			"^(TestFlaky|TestSub)$ pkg/a": {goTestEvents("pkg/a", "TestFlaky:pass", "TestSub/one:fail", "TestSub:fail")},
+			"^(TestB)$ pkg/b":             {goTestEvents("pkg/b", "TestB:pass")},
+			"^(TestSub)$ pkg/a":           {goTestEvents("pkg/a", "TestSub/one:fail", "TestSub:fail")},
+		})
+		output := filepath.Join(tempDir, "test-report-run.json")
+
+		err := execute(ctx, []string{"run", "-output", output, "-rerunFails", "2", "--", "-count", "1"})
+		require.Equal(t, exitTestFailure, exitCode(err))
+
+		assert.Equal(t, []string{
+			"test -json -count 1 ./...",
+			"test -json -count 1 -run ^(TestFlaky|TestSub)$ pkg/a",
+			"test -json -count 1 -run ^(TestB)$ pkg/b",
+			"test -json -count 1 -run ^(TestSub)$ pkg/a",
+		}, calls)
+
+		report, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+
+		results := make(map[string]*ctrf.TestResult)
+		for _, test := range report.Results.Tests {
+			results[test.Name] = test
+		}
+		assert.True(t, results["TestFlaky"].Flaky)
+		assert.Equal(t, ctrf.TestPassed, results["TestFlaky"].Status)
+		assert.Len(t, results["TestFlaky"].RetryAttempts, 2)
+		assert.True(t, results["TestB"].Flaky)
+		assert.Equal(t, ctrf.TestFailed, results["TestSub"].Status)
+		assert.Len(t, results["TestSub/one"].RetryAttempts, 3)
+		assert.Nil(t, results["TestOK"].RetryAttempts)
+		assert.Equal(t, 2, report.Results.Summary.Failed)
+		assert.Equal(t, 2, report.Results.Summary.Flaky)
+	})
+
+	t.Run("should not rerun too many failures", func(t *testing.T) {
+		var calls []string
+		ctx := freshContext(nil, nil)
+		ctx.goTest = fakeGoTest(&calls, map[string][]string{
+			"./pkg/...": {goTestEvents("pkg/a", "TestA:fail", "TestB:fail")},
+		})
+
+		err := execute(ctx, []string{"run", "-output", filepath.Join(tempDir, "test-report-max.json"), "-packages", "./pkg/...", "-rerunFailsMaxFailures", "1"})
+		require.Equal(t, exitTestFailure, exitCode(err))
+
+		assert.Equal(t, []string{"test -json ./pkg/..."}, calls)
+		assert.Contains(t, ctx.errWriter.(fmt.Stringer).String(), "not rerunning failed tests: 2 tests failed")
+	})
+}
+
+// fakeGoTest replays the output of go test, keyed by the last arguments (i.e. the -run pattern and the package).
+//
+// The outputs of a key are replayed in order, one per call.
+func fakeGoTest(calls *[]string, outputs map[string][]string) goTestFunc {
+	return func(args []string, stdout io.Writer) error {
+		*calls = append(*calls, strings.Join(args, " "))
+
+		key := args[len(args)-1]
+		if len(args) > 2 && args[len(args)-3] == "-run" {
+			key = args[len(args)-2] + " " + key
+		}
+
+		replayed := outputs[key]
+		if len(replayed) == 0 {
+			return fmt.Errorf("unexpected go test call: %v", args)
+		}
+		outputs[key] = replayed[1:]
+
+		_, err := io.WriteString(stdout, replayed[0])
+
+		return err
+	}
+}
+
+// goTestEvents renders the go test -json events of a package, from outcomes like "TestName:fail".
+func goTestEvents(pkg string, outcomes ...string) string {
+	var b strings.Builder
+
+	packageAction := "pass"
+	for _, outcome := range outcomes {
+		name, action, _ := strings.Cut(outcome, ":")
+		if action == "fail" {
+			packageAction = "fail"
+		}
+		fmt.Fprintf(&b, `{"Time":"2024-01-01T00:00:00Z","Action":"run","Package":%q,"Test":%q}`+"\n", pkg, name)
+		fmt.Fprintf(&b, `{"Time":"2024-01-01T00:00:01Z","Action":%q,"Package":%q,"Test":%q,"Elapsed":1}`+"\n", action, pkg, name)
+	}
+	fmt.Fprintf(&b, `{"Time":"2024-01-01T00:00:02Z","Action":%q,"Package":%q,"Elapsed":2}`+"\n", packageAction, pkg)
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/shard"
+)
+
+const (
+	splitByPackage = "package"
+	splitByTest    = "test"
+)
+
+// executeSplit prints the packages, or the -run regular expression of the tests, of a shard.
+//
+// The packages or tests to split are given as arguments, e.g. with "go list ./... | xargs".
+// Without arguments, the ones of the previous report are split.
+func executeSplit(cmd *commandContext, args []string) error {
+	var (
+		reportFile string
+		shards     int
+		index      int
+		by         string
+	)
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter split [flags] [package or test ...]", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&reportFile, "report", "", "A previous CTRF report, with the durations to balance the shards. A missing file splits evenly.")
+	fs.IntVar(&shards, "shards", 1, "The number of shards.")
+	fs.IntVar(&index, "index", 0, "The index of the shard to print, from 0 to shards-1.")
+	fs.StringVar(&by, "by", splitByPackage, `What to split: "package" prints a list of packages, "test" prints a -run regular expression of top-level tests.`)
+
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+	if shards < 1 {
+		return &usageError{err: fmt.Errorf("invalid -shards %d: expected at least 1", shards), usage: usage(fs)}
+	}
+	if index < 0 || index >= shards {
+		return &usageError{err: fmt.Errorf("invalid -index %d: expected from 0 to %d", index, shards-1), usage: usage(fs)}
+	}
+	if by != splitByPackage && by != splitByTest {
+		return &usageError{err: fmt.Errorf("invalid -by %q: expected %q or %q", by, splitByPackage, splitByTest), usage: usage(fs)}
+	}
+	if reportFile == "" && fs.NArg() == 0 {
+		return &usageError{err: errors.New("expected a -report file or the packages or tests to split"), usage: usage(fs)}
+	}
+
+	report := ctrf.NewReport("", nil)
+	if reportFile != "" {
+		previous, err := ctrf.ReadFile(reportFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return err
+		default:
+			report = previous
+		}
+	}
+
+	var items []string
+	if fs.NArg() > 0 {
+		items = fs.Args()
+	}
+
+	if by == splitByTest {
+		selected := shard.Split(items, shard.TestDurations(report), shards)[index]
+		_, err := fmt.Fprintln(cmd.writer, runPattern(selected.Items))
+
+		return err
+	}
+
+	selected := shard.Split(items, shard.PackageDurations(report), shards)[index]
+	for _, pkg := range selected.Items {
+		if _, err := fmt.Fprintln(cmd.writer, pkg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runPattern builds the "go test -run" regular expression that selects exactly the top-level tests.
+//
+// An empty list of tests selects no test.
+func runPattern(tests []string) string {
+	quoted := make([]string, 0, len(tests))
+	for _, test := range tests {
+		quoted = append(quoted, regexp.QuoteMeta(test))
+	}
+
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
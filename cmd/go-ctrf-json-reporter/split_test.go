@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteSplit(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	report := ctrf.NewReport("gotest", nil)
+	report.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg/a"}, Name: "TestSlow", Status: ctrf.TestPassed, Duration: 900},
+		{Suite: []string{"pkg/b"}, Name: "TestFast", Status: ctrf.TestPassed, Duration: 100},
+		{Suite: []string{"pkg/c"}, Name: "TestFast.Too", Status: ctrf.TestPassed, Duration: 200},
+	}
+	reportFile := filepath.Join(tempDir, "report.json")
+	require.NoError(t, reporter.WriteReportToFile(reportFile, report))
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "should print the packages of a shard",
+			args:     []string{"-report", reportFile, "-shards", "2", "-index", "1"},
+			expected: "pkg/b\npkg/c\n",
+		},
+		{
+			name:     "should print the -run pattern of a shard",
+			args:     []string{"-report", reportFile, "-shards", "2", "-index", "1", "-by", "test"},
+			expected: "^(TestFast|TestFast\\.Too)$\n",
+		},
+		{
+			name:     "should split the packages given as arguments",
+			args:     []string{"-report", reportFile, "-shards", "2", "-index", "1", "pkg/a", "pkg/new"},
+			expected: "pkg/new\n",
+		},
+		{
+			name:     "should split evenly without a previous report",
+			args:     []string{"-report", filepath.Join(tempDir, "missing.json"), "-shards", "2", "-index", "0", "pkg/x", "pkg/y", "pkg/z"},
+			expected: "pkg/x\npkg/z\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			require.NoError(t, execute(freshContext(&stdout, nil), append([]string{"split"}, tc.args...)))
+			require.Equal(t, tc.expected, stdout.String())
+		})
+	}
+
+	t.Run("should reject an invalid index", func(t *testing.T) {
+		err := execute(freshContext(nil, nil), []string{"split", "-report", reportFile, "-shards", "2", "-index", "2"})
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.ErrorContains(t, err, "invalid -index 2")
+	})
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/trend"
+)
+
+// trendFlags stores the flags of the duration trend analysis, shared by the default command and the trend subcommand.
+type trendFlags struct {
+	trend         bool
+	trendBaseline string
+	trendFactor   float64
+	trendMinDelta int64
+	trendTop      int
+}
+
+var errNoBaseline = errors.New("the duration trend analysis requires a -trendBaseline or a -history directory")
+
+func registerTrendFlags(fs *flag.FlagSet, flags *trendFlags) {
+	fs.StringVar(&flags.trendBaseline, "trendBaseline", "", "A glob pattern of past CTRF reports to compare test durations with. Defaults to the -history directory.")
+	fs.Float64Var(&flags.trendFactor, "trendFactor", trend.DefaultOptions.Factor, "How many times slower than its median past duration a test must be to be reported as a regression.")
+	fs.Int64Var(&flags.trendMinDelta, "trendMinDelta", trend.DefaultOptions.MinDelta, "The minimum slowdown, in milliseconds, for a test to be reported as a regression.")
+	fs.IntVar(&flags.trendTop, "trendTop", trend.DefaultOptions.Top, "The number of slowest tests and packages to report.")
+}
+
+// analyzeTrend compares the durations of the tests of the report with the baseline of past runs.
+func analyzeTrend(flags trendFlags, historyFlags historyFlags, report *ctrf.Report) (*trend.Analysis, error) {
+	baseline, err := loadBaseline(flags, historyFlags, report.ReportId)
+	if err != nil {
+		return nil, err
+	}
+
+	return trend.Analyze(report, baseline, trend.Options{
+		Factor:   flags.trendFactor,
+		MinDelta: flags.trendMinDelta,
+		Top:      flags.trendTop,
+	}), nil
+}
+
+// loadBaseline loads the past runs from the reports matching -trendBaseline, or else from the history store.
+//
+// The run of the current report is excluded, in case it was already ingested.
+func loadBaseline(flags trendFlags, historyFlags historyFlags, currentID string) (*trend.Baseline, error) {
+	baseline := trend.NewBaseline()
+
+	switch {
+	case flags.trendBaseline != "":
+		filenames, err := filepath.Glob(flags.trendBaseline)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -trendBaseline pattern: %w", err)
+		}
+
+		for _, filename := range filenames {
+			report, err := ctrf.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			if currentID != "" && report.ReportId == currentID {
+				continue
+			}
+			baseline.AddReport(report)
+		}
+	case historyFlags.historyDir != "":
+		store, err := history.Open(historyFlags.historyDir)
+		if err != nil {
+			return nil, err
+		}
+		runs, err := store.Runs(historyFlags.historyRuns)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, run := range runs {
+			if currentID != "" && run.ReportID == currentID {
+				continue
+			}
+			baseline.AddRun(run)
+		}
+	default:
+		return nil, errNoBaseline
+	}
+
+	return baseline, nil
+}
+
+// executeTrend prints the duration trend analysis of a CTRF report.
+func executeTrend(cmd *commandContext, args []string) error {
+	var (
+		flags        trendFlags
+		historyFlags historyFlags
+		markdown     bool
+	)
+
+	fs := flag.NewFlagSet("go-ctrf-json-reporter trend [flags] <report.json>", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	registerTrendFlags(fs, &flags)
+	registerHistoryFlags(fs, &historyFlags)
+	fs.BoolVar(&markdown, "markdown", false, "Print the analysis as Markdown, e.g. for a pull request comment or a job summary.")
+
+	if err := fs.Parse(args); err != nil {
+		return &usageError{err: err, usage: usage(fs)}
+	}
+	if fs.NArg() != 1 {
+		return &usageError{err: fmt.Errorf("expected a single report file, got %d argument(s)", fs.NArg()), usage: usage(fs)}
+	}
+	if flags.trendBaseline == "" && historyFlags.historyDir == "" {
+		return &usageError{err: errNoBaseline, usage: usage(fs)}
+	}
+
+	report, err := ctrf.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	analysis, err := analyzeTrend(flags, historyFlags, report)
+	if err != nil {
+		return err
+	}
+
+	if markdown {
+		return analysis.WriteMarkdown(cmd.writer)
+	}
+
+	return analysis.WriteText(cmd.writer)
+}
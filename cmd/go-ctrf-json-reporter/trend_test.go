@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteTrend(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+
+	for i, duration := range []int64{100, 110, 120} {
+		report := ctrf.NewReport("gotest", nil)
+		report.Results.Tests = []*ctrf.TestResult{
+			{Suite: []string{"pkg"}, Name: "TestSlow", Status: ctrf.TestPassed, Duration: duration},
+		}
+		require.NoError(t, reporter.WriteReportToFile(filepath.Join(tempDir, "baseline-"+string(rune('0'+i))+".json"), report))
+	}
+
+	current := ctrf.NewReport("gotest", nil)
+	current.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg"}, Name: "TestSlow", Status: ctrf.TestPassed, Duration: 500},
+	}
+	currentFile := filepath.Join(tempDir, "current.json")
+	require.NoError(t, reporter.WriteReportToFile(currentFile, current))
+
+	t.Run("should print regressions", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := execute(freshContext(&stdout, nil), []string{"trend", "-trendBaseline", filepath.Join(tempDir, "baseline-*.json"), currentFile})
+		require.NoError(t, err)
+		require.Contains(t, stdout.String(), "  pkg.TestSlow  500ms  p50 110ms  p95 120ms  4.5x\n")
+	})
+
+	t.Run("should print Markdown", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := execute(freshContext(&stdout, nil), []string{"trend", "-markdown", "-trendBaseline", filepath.Join(tempDir, "*.json"), currentFile})
+		require.NoError(t, err)
+		require.Contains(t, stdout.String(), "| `pkg.TestSlow` | 500ms | 110ms | 120ms | 4.5x |\n", "the current report is not part of its baseline")
+	})
+
+	t.Run("should add the analysis to the report", func(t *testing.T) {
+		fixture, err := os.Open(filepath.Join("testdata", "test.json"))
+		require.NoError(t, err)
+		defer func() {
+			_ = fixture.Close()
+		}()
+		output := filepath.Join(tempDir, "test-report-trend.json")
+
+		err = execute(freshContext(nil, fixture), []string{"-trend", "-trendBaseline", filepath.Join(tempDir, "baseline-*.json"), "-output", output})
+		require.NoError(t, err)
+
+		report, err := ctrf.ReadFile(output)
+		require.NoError(t, err)
+		extra := reporter.ReportExtra(report)
+		require.NotNil(t, extra)
+		require.NotNil(t, extra.DurationTrend)
+	})
+
+	t.Run("should require a baseline", func(t *testing.T) {
+		err := execute(freshContext(nil, nil), []string{"-trend"})
+
+		var usageErr *usageError
+		require.ErrorAs(t, err, &usageErr)
+		require.ErrorIs(t, err, errNoBaseline)
+	})
+}
@@ -0,0 +1,177 @@
+package ctrf
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReportBuilder builds a report from the results of tests, keeping its summary consistent with them,
+// for test harnesses that are not go test. It is safe for concurrent use.
+//
+// The counts of the summary follow the statuses of the tests, retries included, as described by Summary. The suites of the summary
+// are the distinct suites of the tests, at every level of their hierarchy, along with the suites started
+// without tests. The summary starts with the earliest test, or when the builder was created if the tests
+// are not timed, and stops with the latest test, or when the report is finished.
+type ReportBuilder struct {
+	mu      sync.Mutex
+	report  *Report
+	created int64
+	suites  map[string]bool
+	tests   map[string]*TestResult
+	timed   bool
+}
+
+// NewReportBuilder returns a builder of a report of a tool, run in an environment.
+//
+// A report without tests is valid, with an empty list of tests.
+func NewReportBuilder(toolName string, env *Environment) *ReportBuilder {
+	report := NewReport(toolName, env)
+	report.Results.Tests = []*TestResult{}
+
+	return &ReportBuilder{
+		report:  report,
+		created: time.Now().UnixMilli(),
+		suites:  make(map[string]bool),
+		tests:   make(map[string]*TestResult),
+	}
+}
+
+// StartSuite records a suite, given as its hierarchy, e.g. the suites that have no tests.
+func (b *ReportBuilder) StartSuite(suite ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.report.Results.Summary.Suites += addSuites(b.suites, suite)
+}
+
+// AddTest adds the result of a test.
+func (b *ReportBuilder) AddTest(test *TestResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.addLocked(test)
+}
+
+// addLocked adds the result of a test, with the lock held.
+func (b *ReportBuilder) addLocked(test *TestResult) {
+	b.report.Results.Summary.Suites += addSuites(b.suites, test.Suite)
+	b.tests[testKey(test.Suite, test.Name)] = test
+	b.report.Results.Tests = append(b.report.Results.Tests, test)
+	b.report.Results.Summary.count(test, 1)
+	b.extend(test.Start, test.Stop)
+}
+
+// AddRetry records another attempt of a test that was added before, in the same suite and with the same name.
+// A test that was not added before is added.
+//
+// The attempts of the test are recorded as its retry attempts, and the test takes the status of the last attempt,
+// the sum of their durations, and the period from the first to the last attempt. A test that passes after
+// failed attempts is flaky.
+func (b *ReportBuilder) AddRetry(attempt *TestResult) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	test, ok := b.tests[testKey(attempt.Suite, attempt.Name)]
+	if !ok {
+		b.addLocked(attempt)
+
+		return
+	}
+
+	b.report.Results.Summary.count(test, -1)
+
+	// The first retry moves the first attempt to the retry attempts.
+	if test.RetryAttempts == nil {
+		test.Retries = 1
+		test.RetryAttempts = append(test.RetryAttempts, RetryAttempt{
+			Attempt:     1,
+			Status:      test.Status,
+			Message:     test.Message,
+			Trace:       test.Trace,
+			Line:        test.Line,
+			Duration:    test.Duration,
+			Start:       test.Start,
+			Stop:        test.Stop,
+			Attachments: append([]Attachment(nil), test.Attachments...),
+		})
+	}
+
+	test.Retries++
+	test.RetryAttempts = append(test.RetryAttempts, RetryAttempt{
+		Attempt:     test.Retries,
+		Status:      attempt.Status,
+		Message:     attempt.Message,
+		Trace:       attempt.Trace,
+		Line:        attempt.Line,
+		Duration:    attempt.Duration,
+		Start:       attempt.Start,
+		Stop:        attempt.Stop,
+		Attachments: attempt.Attachments,
+	})
+
+	test.Status = attempt.Status
+	test.Flaky = attempt.Status == TestPassed && failedBefore(test.RetryAttempts)
+
+	// The messages are in the retry attempts.
+	test.Message = ""
+	test.Trace = ""
+	test.Line = 0
+
+	test.Duration += attempt.Duration
+	if attempt.Start != 0 && (test.Start == 0 || attempt.Start < test.Start) {
+		test.Start = attempt.Start
+	}
+	if attempt.Stop > test.Stop {
+		test.Stop = attempt.Stop
+	}
+
+	// Keep the attachments of every attempt on the test.
+	test.Attachments = append(test.Attachments, attempt.Attachments...)
+
+	b.report.Results.Summary.count(test, 1)
+	b.extend(attempt.Start, attempt.Stop)
+}
+
+// Finish completes the summary of the report, and returns the report. The builder must not be used afterwards.
+func (b *ReportBuilder) Finish() *Report {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	summary := b.report.Results.Summary
+	if !b.timed {
+		summary.Start = b.created
+		summary.Stop = time.Now().UnixMilli()
+	}
+	summary.Duration = summary.Stop - summary.Start
+
+	return b.report
+}
+
+// extend extends the period of the summary to the period of a test.
+func (b *ReportBuilder) extend(start, stop int64) {
+	if start == 0 && stop == 0 {
+		return
+	}
+
+	summary := b.report.Results.Summary
+	if !b.timed {
+		summary.Start, summary.Stop = start, stop
+		b.timed = true
+	}
+	summary.extend(start, stop)
+}
+
+func failedBefore(attempts []RetryAttempt) bool {
+	for _, attempt := range attempts[:len(attempts)-1] {
+		if attempt.Status == TestFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func testKey(suite []string, name string) string {
+	return strings.Join(suite, "\x00") + "\x00\x00" + name
+}
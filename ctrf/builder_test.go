@@ -0,0 +1,143 @@
+package ctrf
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportBuilder(t *testing.T) {
+	t.Run("should keep the summary consistent with the tests", func(t *testing.T) {
+		builder := NewReportBuilder("pytest", &Environment{AppName: "app"})
+		builder.StartSuite("api", "empty")
+		builder.AddTest(&TestResult{Name: "lists", Suite: []string{"api", "users"}, Status: TestPassed, Duration: 10, Start: 2000, Stop: 2010})
+		builder.AddTest(&TestResult{Name: "creates", Suite: []string{"api", "users"}, Status: TestFailed, Message: "boom", Duration: 20, Start: 2010, Stop: 2030})
+		builder.AddTest(&TestResult{Name: "pays", Suite: []string{"web"}, Status: TestSkipped, Start: 1000, Stop: 1000})
+		builder.AddTest(&TestResult{Name: "draft", Suite: []string{"web"}, Status: TestPending})
+
+		report := builder.Finish()
+		require.Empty(t, report.Validate())
+
+		assert.Equal(t, "pytest", report.Results.Tool.Name)
+		assert.Equal(t, "app", report.Results.Environment.AppName)
+		assert.Equal(t, &Summary{
+			Tests: 4, Passed: 1, Failed: 1, Skipped: 1, Pending: 1, Suites: 4,
+			Start: 1000, Stop: 2030, Duration: 1030,
+		}, report.Results.Summary)
+		assert.Len(t, report.Results.Tests, 4)
+	})
+
+	t.Run("should build a valid report without tests", func(t *testing.T) {
+		report := NewReportBuilder("pytest", nil).Finish()
+		require.Empty(t, report.Validate())
+		assert.Equal(t, []*TestResult{}, report.Results.Tests)
+
+		var buf strings.Builder
+		require.NoError(t, report.Write(&buf, false))
+		assert.Contains(t, buf.String(), `"tests":[]`)
+	})
+
+	t.Run("should record retries", func(t *testing.T) {
+		builder := NewReportBuilder("pytest", nil)
+		builder.AddTest(&TestResult{Name: "flaky", Suite: []string{"api"}, Status: TestFailed, Message: "timeout", Duration: 100, Start: 1000, Stop: 1100})
+		builder.AddRetry(&TestResult{Name: "flaky", Suite: []string{"api"}, Status: TestFailed, Message: "timeout", Duration: 100, Start: 1100, Stop: 1200})
+		builder.AddRetry(&TestResult{Name: "flaky", Suite: []string{"api"}, Status: TestPassed, Duration: 50, Start: 1200, Stop: 1250})
+		builder.AddRetry(&TestResult{Name: "broken", Suite: []string{"api"}, Status: TestPassed, Duration: 10, Start: 1300, Stop: 1310})
+		builder.AddRetry(&TestResult{Name: "broken", Suite: []string{"api"}, Status: TestFailed, Message: "boom", Duration: 10, Start: 1310, Stop: 1320})
+
+		report := builder.Finish()
+		require.Empty(t, report.Validate())
+		assert.Equal(t, &Summary{Tests: 2, Passed: 1, Failed: 1, Flaky: 1, Suites: 1, Start: 1000, Stop: 1320, Duration: 320}, report.Results.Summary)
+
+		flaky := report.Results.Tests[0]
+		assert.Equal(t, TestPassed, flaky.Status)
+		assert.True(t, flaky.Flaky)
+		assert.Empty(t, flaky.Message)
+		assert.Equal(t, 3, flaky.Retries)
+		assert.Equal(t, int64(250), flaky.Duration)
+		assert.Equal(t, int64(1250), flaky.Stop)
+		assert.Equal(t, []RetryAttempt{
+			{Attempt: 1, Status: TestFailed, Message: "timeout", Duration: 100, Start: 1000, Stop: 1100},
+			{Attempt: 2, Status: TestFailed, Message: "timeout", Duration: 100, Start: 1100, Stop: 1200},
+			{Attempt: 3, Status: TestPassed, Duration: 50, Start: 1200, Stop: 1250},
+		}, flaky.RetryAttempts)
+
+		broken := report.Results.Tests[1]
+		assert.Equal(t, TestFailed, broken.Status)
+		assert.False(t, broken.Flaky)
+		assert.Equal(t, 2, broken.Retries)
+	})
+
+	t.Run("should time untimed tests with the builder", func(t *testing.T) {
+		before := time.Now().UnixMilli()
+		builder := NewReportBuilder("pytest", nil)
+		builder.AddTest(&TestResult{Name: "untimed", Status: TestPassed})
+
+		report := builder.Finish()
+		require.Empty(t, report.Validate())
+		assert.GreaterOrEqual(t, report.Results.Summary.Start, before)
+		assert.GreaterOrEqual(t, report.Results.Summary.Stop, report.Results.Summary.Start)
+		assert.Equal(t, 0, report.Results.Summary.Suites)
+	})
+
+	t.Run("should be safe for concurrent use", func(t *testing.T) {
+		builder := NewReportBuilder("pytest", nil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				suite := fmt.Sprintf("suite%d", i%4)
+				builder.StartSuite(suite)
+				builder.AddTest(&TestResult{Name: fmt.Sprintf("test%d", i), Suite: []string{suite}, Status: TestFailed})
+				builder.AddRetry(&TestResult{Name: fmt.Sprintf("test%d", i), Suite: []string{suite}, Status: TestPassed})
+			}(i)
+		}
+		wg.Wait()
+
+		report := builder.Finish()
+		require.Empty(t, report.Validate())
+		assert.Equal(t, 20, report.Results.Summary.Tests)
+		assert.Equal(t, 20, report.Results.Summary.Flaky)
+		assert.Equal(t, 20, report.Results.Summary.Passed)
+		assert.Equal(t, 4, report.Results.Summary.Suites)
+	})
+
+	t.Run("should add a new test once when retried concurrently", func(t *testing.T) {
+		builder := NewReportBuilder("pytest", nil)
+
+		// The goroutines start together, to retry the same new tests at once.
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+
+				status := TestFailed
+				if i%2 == 0 {
+					status = TestPassed
+				}
+				builder.AddRetry(&TestResult{Name: fmt.Sprintf("test%d", i%5), Suite: []string{"suite"}, Status: status})
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		report := builder.Finish()
+		require.Empty(t, report.Validate())
+		require.Len(t, report.Results.Tests, 5)
+		assert.Equal(t, 5, report.Results.Summary.Tests)
+		for _, test := range report.Results.Tests {
+			assert.Len(t, test.RetryAttempts, 40, test.Name)
+		}
+	})
+}
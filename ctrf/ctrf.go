@@ -2,7 +2,6 @@ package ctrf
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -19,12 +18,15 @@ type Report struct {
 	Timestamp    time.Time `json:"timestamp,omitempty"`
 	GeneratedBy  string    `json:"generatedBy,omitempty"`
 	Results      *Results  `json:"results"`
+	Insights     *Insights `json:"insights,omitempty"`
+	Baseline     *Baseline `json:"baseline,omitempty"`
 	Extra        any       `json:"extra,omitempty"`
 }
 
 const (
-	ReportFormatCTRF   = "CTRF"
-	SpecVersionCTRF    = "0.0.0"
+	ReportFormatCTRF = "CTRF"
+	// SpecVersionCTRF is the version of the CTRF specification of new reports: the latest supported version.
+	SpecVersionCTRF    = SpecVersion1
 	GeneratedByDefault = "go-ctrf-json-reporter"
 )
 
@@ -65,10 +67,15 @@ func (report *Report) ToJsonPretty() (string, error) {
 	return stringBuilder.String(), nil
 }
 
+// Write encodes the report as JSON, after validating it.
+//
+// An invalid report is not written, and the error is the ValidationErrors that list the invariants it breaks.
+// A summary that does not match the tests can be fixed with RecomputeSummary.
 func (report *Report) Write(w io.Writer, pretty bool) error {
-	if len(report.Validate()) > 0 {
-		return errors.New("report is invalid")
-	}
+	return report.WriteWith(w, WriteOptions{Pretty: pretty})
+}
+
+func (report *Report) encode(w io.Writer, pretty bool) error {
 	encoder := json.NewEncoder(w)
 	if pretty {
 		encoder.SetIndent("", "  ")
@@ -81,44 +88,62 @@ func (report *Report) Write(w io.Writer, pretty bool) error {
 }
 
 func (report *Report) WriteFile(filePath string) error {
-	file, err := os.Create(filePath)
+	return report.WriteFileWith(filePath, WriteOptions{Pretty: true})
+}
+
+// Read decodes a CTRF JSON report.
+//
+// Reports of an older version of the specification are upgraded to SpecVersionCTRF,
+// and reports of a newer major version are rejected.
+func Read(r io.Reader) (*Report, error) {
+	report := &Report{}
+	if err := json.NewDecoder(r).Decode(report); err != nil {
+		return nil, fmt.Errorf("error reading ctrf json report: %w", err)
+	}
+
+	if err := report.ConvertTo(SpecVersionCTRF); err != nil {
+		return nil, fmt.Errorf("error reading ctrf json report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ReadFile decodes a CTRF JSON report from a file.
+func ReadFile(filePath string) (*Report, error) {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return fmt.Errorf("error writing ctrf json report: %v", err)
+		return nil, fmt.Errorf("error reading ctrf json report: %w", err)
 	}
 	defer func(file *os.File) {
 		_ = file.Close()
 	}(file)
 
-	return report.Write(file, true)
+	return Read(file)
 }
 
 func (report *Report) Validate() []error {
 	results := report.Results
 	if results == nil {
-		return singleError("missing property 'results'")
+		return []error{missingProperty("results")}
 	}
 
 	var errs []error
 	if results.Tool == nil {
-		errs = append(errs, errors.New("missing property 'results.tool'"))
+		errs = append(errs, missingProperty("results.tool"))
 	} else {
 		errs = append(errs, results.Tool.Validate()...)
 	}
 	if results.Summary == nil {
-		errs = append(errs, errors.New("missing property 'results.summary'"))
+		errs = append(errs, missingProperty("results.summary"))
 	} else {
-		errs = append(errs, results.Summary.Validate()...)
+		errs = append(errs, results.Summary.validate(report.SpecVersion == SpecVersion0)...)
 	}
 	if results.Tests == nil {
-		errs = append(errs, errors.New("missing property 'results.tests'"))
+		errs = append(errs, missingProperty("results.tests"))
 	}
 	return errs
 }
 
-func singleError(errorMessage string) []error {
-	return []error{errors.New(errorMessage)}
-}
-
 type Results struct {
 	Tool        *Tool         `json:"tool"`
 	Summary     *Summary      `json:"summary"`
@@ -135,63 +160,85 @@ type Tool struct {
 
 func (tool *Tool) Validate() []error {
 	if tool.Name == "" {
-		return singleError("missing property 'results.tool.name'")
+		return []error{missingProperty("results.tool.name")}
 	}
 	return nil
 }
 
+// Summary sums up the tests of a report.
+//
+// Every test is counted once, under its status, so that Tests is the sum of Passed, Failed, Pending, Skipped
+// and Other. Flaky counts the flaky tests on top of that, e.g. a flaky test that passed on retry is counted
+// both in Passed and in Flaky.
 type Summary struct {
-	Tests   int   `json:"tests"`
-	Passed  int   `json:"passed"`
-	Failed  int   `json:"failed"`
-	Pending int   `json:"pending"`
-	Skipped int   `json:"skipped"`
-	Other   int   `json:"other"`
-	Flaky   int   `json:"flaky,omitempty"`
-	Suites  int   `json:"suites,omitempty"`
-	Start   int64 `json:"start"`
-	Stop    int64 `json:"stop"`
-	Extra   any   `json:"extra,omitempty"`
+	Tests    int   `json:"tests"`
+	Passed   int   `json:"passed"`
+	Failed   int   `json:"failed"`
+	Pending  int   `json:"pending"`
+	Skipped  int   `json:"skipped"`
+	Other    int   `json:"other"`
+	Flaky    int   `json:"flaky,omitempty"`
+	Suites   int   `json:"suites,omitempty"`
+	Start    int64 `json:"start"`
+	Stop     int64 `json:"stop"`
+	Duration int64 `json:"duration,omitempty"`
+	Extra    any   `json:"extra,omitempty"`
 }
 
 func (summary *Summary) Validate() []error {
+	return summary.validate(false)
+}
+
+// validate checks the summary, which counts the flaky tests apart from the other statuses with flakyApart,
+// as in version 0 of the specification.
+func (summary *Summary) validate(flakyApart bool) []error {
 	var errs []error
 	if summary.Tests < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.tests'"))
+		errs = append(errs, invalidProperty("results.summary.tests"))
 	}
 	if summary.Passed < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.passed'"))
+		errs = append(errs, invalidProperty("results.summary.passed"))
 	}
 	if summary.Failed < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.failed'"))
+		errs = append(errs, invalidProperty("results.summary.failed"))
 	}
 	if summary.Pending < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.pending'"))
+		errs = append(errs, invalidProperty("results.summary.pending"))
 	}
 	if summary.Skipped < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.skipped'"))
+		errs = append(errs, invalidProperty("results.summary.skipped"))
 	}
 	if summary.Other < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.other'"))
+		errs = append(errs, invalidProperty("results.summary.other"))
 	}
 	if summary.Start < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.start'"))
+		errs = append(errs, invalidProperty("results.summary.start"))
 	}
 	if summary.Stop < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.stop'"))
+		errs = append(errs, invalidProperty("results.summary.stop"))
 	}
 	if summary.Flaky < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.flaky'"))
+		errs = append(errs, invalidProperty("results.summary.flaky"))
 	}
 	if summary.Suites < 0 {
-		errs = append(errs, errors.New("invalid property 'results.summary.suites'"))
+		errs = append(errs, invalidProperty("results.summary.suites"))
+	}
+	if summary.Flaky > summary.Tests {
+		errs = append(errs, inconsistentProperty("results.summary.flaky", "invalid summary counts: flaky (%d) can't be greater than tests (%d)", summary.Flaky, summary.Tests))
 	}
 	if summary.Start > summary.Stop {
-		errs = append(errs, errors.New("invalid summary timestamps: start can't be greater than stop"))
+		errs = append(errs, inconsistentProperty("results.summary.start", "invalid summary timestamps: start can't be greater than stop"))
 	}
-	testsSum := summary.Passed + summary.Failed + summary.Pending + summary.Skipped + summary.Other + summary.Flaky
-	if summary.Tests != testsSum {
-		errs = append(errs, fmt.Errorf("invalid summary counts: tests (%d) must be the sum of passed, failed, pending, skipped, and other (%d)", summary.Tests, testsSum))
+	testsSum := summary.statusSum()
+	if flakyApart {
+		testsSum += summary.Flaky
+		if summary.Tests != testsSum {
+			errs = append(errs, inconsistentProperty("results.summary.tests", "invalid summary counts: tests (%d) must be the sum of passed (%d), failed (%d), pending (%d), skipped (%d), other (%d), and flaky (%d), i.e. %d",
+				summary.Tests, summary.Passed, summary.Failed, summary.Pending, summary.Skipped, summary.Other, summary.Flaky, testsSum))
+		}
+	} else if summary.Tests != testsSum {
+		errs = append(errs, inconsistentProperty("results.summary.tests", "invalid summary counts: tests (%d) must be the sum of passed (%d), failed (%d), pending (%d), skipped (%d), and other (%d), i.e. %d",
+			summary.Tests, summary.Passed, summary.Failed, summary.Pending, summary.Skipped, summary.Other, testsSum))
 	}
 	return errs
 }
@@ -207,21 +254,23 @@ const (
 )
 
 type RetryAttempt struct {
-	Attempt  int        `json:"attempt"`
-	Status   TestStatus `json:"status"`
-	Duration int64      `json:"duration,omitempty"`
-	Message  string     `json:"message,omitempty"`
-	Trace    string     `json:"trace,omitempty"`
-	Line     int        `json:"line,omitempty"`
-	Snippet  string     `json:"snippet,omitempty"`
-	Stdout   []string   `json:"stdout,omitempty"`
-	Stderr   []string   `json:"stderr,omitempty"`
-	Start    int64      `json:"start,omitempty"`
-	Stop     int64      `json:"stop,omitempty"`
-	Extra    any        `json:"extra,omitempty"`
+	Attempt     int          `json:"attempt"`
+	Status      TestStatus   `json:"status"`
+	Duration    int64        `json:"duration,omitempty"`
+	Message     string       `json:"message,omitempty"`
+	Trace       string       `json:"trace,omitempty"`
+	Line        int          `json:"line,omitempty"`
+	Snippet     string       `json:"snippet,omitempty"`
+	Stdout      []string     `json:"stdout,omitempty"`
+	Stderr      []string     `json:"stderr,omitempty"`
+	Start       int64        `json:"start,omitempty"`
+	Stop        int64        `json:"stop,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Extra       any          `json:"extra,omitempty"`
 }
 
 type TestResult struct {
+	ID            string         `json:"id,omitempty"`
 	Name          string         `json:"name"`
 	Status        TestStatus     `json:"status"`
 	Duration      int64          `json:"duration"`
@@ -230,28 +279,106 @@ type TestResult struct {
 	Suite         []string       `json:"suite,omitempty"`
 	Message       string         `json:"message,omitempty"`
 	Trace         string         `json:"trace,omitempty"`
+	Snippet       string         `json:"snippet,omitempty"`
+	AI            string         `json:"ai,omitempty"`
+	Line          int            `json:"line,omitempty"`
 	RawStatus     string         `json:"rawStatus,omitempty"`
 	Tags          []string       `json:"tags,omitempty"`
+	Labels        map[string]any `json:"labels,omitempty"`
 	Type          string         `json:"type,omitempty"`
 	Filepath      string         `json:"filePath,omitempty"`
 	Retries       int            `json:"retries,omitempty"`
 	Flaky         bool           `json:"flaky,omitempty"`
+	Stdout        []string       `json:"stdout,omitempty"`
+	Stderr        []string       `json:"stderr,omitempty"`
+	ThreadID      string         `json:"threadId,omitempty"`
 	Browser       string         `json:"browser,omitempty"`
 	Device        string         `json:"device,omitempty"`
 	Screenshot    string         `json:"screenshot,omitempty"`
+	Attachments   []Attachment   `json:"attachments,omitempty"`
 	Parameters    any            `json:"parameters,omitempty"`
-	Steps         []any          `json:"steps,omitempty"`
+	Steps         []any          `json:"steps,omitempty"` // e.g. Step values
 	RetryAttempts []RetryAttempt `json:"retryAttempts,omitempty"`
+	Insights      *TestInsights  `json:"insights,omitempty"`
 	Extra         any            `json:"extra,omitempty"`
 }
 
+// Attachment is a file attached to a test, e.g. a screenshot or a log.
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Path        string `json:"path"`
+	Extra       any    `json:"extra,omitempty"`
+}
+
+// Step is a step of a test, as listed in the Steps of a TestResult.
+//
+// Steps are kept as []any, as they were before the specification defined them: they may hold Step values,
+// and hold generic JSON values once read.
+type Step struct {
+	Name   string     `json:"name"`
+	Status TestStatus `json:"status"`
+	Extra  any        `json:"extra,omitempty"`
+}
+
 type Environment struct {
-	AppName     string `json:"appName,omitempty"`
-	AppVersion  string `json:"appVersion,omitempty"`
-	OSPlatform  string `json:"osPlatform,omitempty"`
-	OSRelease   string `json:"osRelease,omitempty"`
-	OSVersion   string `json:"osVersion,omitempty"`
-	BuildName   string `json:"buildName,omitempty"`
+	ReportName      string `json:"reportName,omitempty"`
+	AppName         string `json:"appName,omitempty"`
+	AppVersion      string `json:"appVersion,omitempty"`
+	BuildID         string `json:"buildId,omitempty"`
+	BuildName       string `json:"buildName,omitempty"`
+	BuildNumber     string `json:"buildNumber,omitempty"`
+	BuildURL        string `json:"buildUrl,omitempty"`
+	RepositoryName  string `json:"repositoryName,omitempty"`
+	RepositoryURL   string `json:"repositoryUrl,omitempty"`
+	Commit          string `json:"commit,omitempty"`
+	BranchName      string `json:"branchName,omitempty"`
+	OSPlatform      string `json:"osPlatform,omitempty"`
+	OSRelease       string `json:"osRelease,omitempty"`
+	OSVersion       string `json:"osVersion,omitempty"`
+	TestEnvironment string `json:"testEnvironment,omitempty"`
+	Healthy         *bool  `json:"healthy,omitempty"`
+	Extra           any    `json:"extra,omitempty"`
+}
+
+// Insights are the metrics of a run, compared with a baseline of past runs.
+type Insights struct {
+	PassRate            *MetricDelta `json:"passRate,omitempty"`
+	FailRate            *MetricDelta `json:"failRate,omitempty"`
+	FlakyRate           *MetricDelta `json:"flakyRate,omitempty"`
+	AverageRunDuration  *MetricDelta `json:"averageRunDuration,omitempty"`
+	P95RunDuration      *MetricDelta `json:"p95RunDuration,omitempty"`
+	AverageTestDuration *MetricDelta `json:"averageTestDuration,omitempty"`
+	RunsAnalyzed        int          `json:"runsAnalyzed,omitempty"`
+	Extra               any          `json:"extra,omitempty"`
+}
+
+// TestInsights are the metrics of a test across runs, compared with a baseline of past runs.
+type TestInsights struct {
+	PassRate            *MetricDelta `json:"passRate,omitempty"`
+	FailRate            *MetricDelta `json:"failRate,omitempty"`
+	FlakyRate           *MetricDelta `json:"flakyRate,omitempty"`
+	AverageTestDuration *MetricDelta `json:"averageTestDuration,omitempty"`
+	P95TestDuration     *MetricDelta `json:"p95TestDuration,omitempty"`
+	ExecutedInRuns      int          `json:"executedInRuns,omitempty"`
+	Extra               any          `json:"extra,omitempty"`
+}
+
+// MetricDelta is the current value of a metric, its value in the baseline, and the change between them.
+type MetricDelta struct {
+	Current  float64 `json:"current"`
+	Baseline float64 `json:"baseline"`
+	Change   float64 `json:"change"`
+}
+
+// Baseline is the report that the insights of a run compare it with.
+type Baseline struct {
+	ReportId    string `json:"reportId"` //nolint:revive // consistent with Report.ReportId.
+	Timestamp   string `json:"timestamp,omitempty"`
+	Source      string `json:"source,omitempty"`
 	BuildNumber string `json:"buildNumber,omitempty"`
+	BuildName   string `json:"buildName,omitempty"`
+	BuildURL    string `json:"buildUrl,omitempty"`
+	Commit      string `json:"commit,omitempty"`
 	Extra       any    `json:"extra,omitempty"`
 }
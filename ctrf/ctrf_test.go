@@ -3,6 +3,8 @@ package ctrf
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -20,7 +22,7 @@ func TestRequiredProperties(t *testing.T) {
 				Name: "my tool",
 			},
 			Summary: &Summary{
-				Tests:   21,
+				Tests:   20,
 				Passed:  6,
 				Failed:  5,
 				Pending: 4,
@@ -62,14 +64,14 @@ func TestRequiredProperties(t *testing.T) {
 
 	expectedJson := `{
   "reportFormat": "CTRF",
-  "specVersion": "0.0.0",
+  "specVersion": "1.0.0",
   "timestamp": "0001-01-01T00:00:00Z",
   "results": {
     "tool": {
       "name": "my tool"
     },
     "summary": {
-      "tests": 21,
+      "tests": 20,
       "passed": 6,
       "failed": 5,
       "pending": 4,
@@ -146,6 +148,61 @@ func TestSuiteIsSerializedAsArray(t *testing.T) {
 	assert.Equal(t, []any{"parent suite", "child suite"}, decoded["suite"])
 }
 
+func TestStepsAcceptAnyValue(t *testing.T) {
+	// Arrange
+	testResult := TestResult{
+		Name:     "test 1",
+		Status:   TestPassed,
+		Duration: 10,
+		Steps:    []any{Step{Name: "step 1", Status: TestPassed}, map[string]any{"name": "step 2", "status": "failed"}},
+	}
+
+	// Act
+	data, err := json.Marshal(testResult)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert
+	var decoded TestResult
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []any{
+		map[string]any{"name": "step 1", "status": "passed"},
+		map[string]any{"name": "step 2", "status": "failed"},
+	}, decoded.Steps)
+}
+
+func TestReadFile(t *testing.T) {
+	// Arrange
+	report := NewReport("my tool", &Environment{AppName: "my app"})
+	report.Results.Tests = []*TestResult{{Name: "test 1", Status: TestPassed, Duration: 10}}
+	report.Results.Summary = &Summary{Tests: 1, Passed: 1, Start: 42, Stop: 1337}
+	filePath := filepath.Join(t.TempDir(), "report.json")
+	if err := report.WriteFile(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	actual, err := ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert
+	assert.Equal(t, report.ReportId, actual.ReportId)
+	assert.Equal(t, report.Results.Summary, actual.Results.Summary)
+	assert.Equal(t, report.Results.Tests, actual.Results.Tests)
+	assert.Equal(t, report.Results.Environment, actual.Results.Environment)
+
+	_, err = ReadFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+
+	_, err = Read(strings.NewReader("not json"))
+	assert.ErrorContains(t, err, "error reading ctrf json report")
+}
+
 func TestValidation(t *testing.T) {
 	forEachValidationTestCase(t, allTestCase, func(t *testing.T, testCase validationTestCase, report *Report) {
 		t.Helper()
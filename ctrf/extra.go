@@ -0,0 +1,91 @@
+package ctrf
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The extra fields of a report (Report.Extra, Results.Extra, TestResult.Extra...) hold a JSON object,
+// which keys are set by the tools that extend the report. In memory, their values are either the values
+// that were set, e.g. structs, or their JSON decoding (maps, lists...) once the report was read.
+// GetExtra and SetExtra work with both, by round-tripping the values through JSON when needed.
+
+// GetExtra returns the value of a key of an extra field, decoded as a T.
+//
+// It returns false when the field has no such key, or when its value does not decode as a T.
+// A value that is already a T is returned as is, so that a pointer can be updated in place.
+func GetExtra[T any](extra any, key string) (T, bool) {
+	var value T
+
+	object, ok := extraObject(extra)
+	if !ok {
+		return value, false
+	}
+	raw, ok := object[key]
+	if !ok {
+		return value, false
+	}
+	if typed, isTyped := raw.(T); isTyped {
+		return typed, true
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return value, false
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false
+	}
+
+	return value, true
+}
+
+// SetExtra sets a key of an extra field, e.g. SetExtra(&test.Extra, "owners", owners).
+//
+// An empty field is initialized with a new map, and a field that holds another JSON object, e.g. a struct,
+// is converted to a map. Setting a key of a field that holds something else, e.g. a string, fails.
+func SetExtra(extra *any, key string, value any) error {
+	if *extra == nil {
+		*extra = map[string]any{key: value}
+
+		return nil
+	}
+
+	object, ok := extraObject(*extra)
+	if !ok {
+		return fmt.Errorf("error setting extra %q: the extra field is not a JSON object", key)
+	}
+	object[key] = value
+	*extra = object
+
+	return nil
+}
+
+// extraObject returns an extra field as a map, converting it through JSON when it holds another JSON object.
+func extraObject(extra any) (map[string]any, bool) {
+	if object, isMap := extra.(map[string]any); isMap {
+		return object, true
+	}
+	if extra == nil {
+		return nil, false
+	}
+
+	object, isMap := jsonValue(extra).(map[string]any)
+
+	return object, isMap
+}
+
+// jsonValue returns the JSON decoding of a value, i.e. maps, lists and basic values, or the value if it can't.
+func jsonValue(value any) any {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value
+	}
+
+	return decoded
+}
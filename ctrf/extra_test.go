@@ -0,0 +1,73 @@
+package ctrf
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type extension struct {
+	Owner  string   `json:"owner"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+func TestExtra(t *testing.T) {
+	t.Run("should set and get typed values", func(t *testing.T) {
+		var extra any
+		require.NoError(t, SetExtra(&extra, "ext", &extension{Owner: "@team"}))
+		require.NoError(t, SetExtra(&extra, "count", 3))
+
+		ext, ok := GetExtra[*extension](extra, "ext")
+		require.True(t, ok)
+		ext.Labels = append(ext.Labels, "updated in place")
+
+		again, _ := GetExtra[*extension](extra, "ext")
+		assert.Equal(t, &extension{Owner: "@team", Labels: []string{"updated in place"}}, again)
+
+		count, ok := GetExtra[int](extra, "count")
+		assert.True(t, ok)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("should round-trip values through JSON", func(t *testing.T) {
+		test := &TestResult{Name: "TestA", Status: TestPassed}
+		require.NoError(t, SetExtra(&test.Extra, "ext", extension{Owner: "@team", Labels: []string{"slow"}}))
+		require.NoError(t, SetExtra(&test.Extra, "count", 3))
+
+		data, err := json.Marshal(test)
+		require.NoError(t, err)
+		var read TestResult
+		require.NoError(t, json.Unmarshal(data, &read))
+
+		ext, ok := GetExtra[extension](read.Extra, "ext")
+		require.True(t, ok)
+		assert.Equal(t, extension{Owner: "@team", Labels: []string{"slow"}}, ext)
+
+		count, ok := GetExtra[int](read.Extra, "count")
+		assert.True(t, ok)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("should tell missing and mismatched values", func(t *testing.T) {
+		extra := any(map[string]any{"ext": "not an object"})
+
+		_, ok := GetExtra[extension](extra, "missing")
+		assert.False(t, ok)
+		_, ok = GetExtra[extension](extra, "ext")
+		assert.False(t, ok)
+		_, ok = GetExtra[extension](nil, "ext")
+		assert.False(t, ok)
+	})
+
+	t.Run("should convert other JSON objects to maps", func(t *testing.T) {
+		extra := any(extension{Owner: "@team"})
+		require.NoError(t, SetExtra(&extra, "count", 3))
+		assert.Equal(t, map[string]any{"owner": "@team", "count": 3}, extra)
+
+		notAnObject := any("text")
+		require.EqualError(t, SetExtra(&notAnObject, "count", 3), `error setting extra "count": the extra field is not a JSON object`)
+		assert.Equal(t, "text", notAnObject, "a field that is not an object is left untouched")
+	})
+}
@@ -0,0 +1,525 @@
+// Package gotest writes a CTRF report of the tests of a package from its TestMain,
+// for the builds that cannot pipe the output of "go test -json" into the reporter.
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(gotest.Run(m, gotest.Options{}))
+//	}
+//
+// Run executes the tests in a child process of the test binary, in the JSON mode of go test (Go 1.20 or newer),
+// or else in verbose mode, parses their results, and writes a report per package. The reports of the packages are then combined
+// with the merge subcommand of go-ctrf-json-reporter, or with ctrf.Merge.
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// Environment variables of the reports.
+const (
+	// EnvDir overrides the directory of the reports.
+	EnvDir = "CTRF_GOTEST_DIR"
+
+	// envChild marks the child process that runs the tests.
+	envChild = "CTRF_GOTEST_CHILD"
+)
+
+// DefaultDir is the directory of the reports, relative to the root of the module, unless Options.Dir or EnvDir set it.
+const DefaultDir = "ctrf-reports"
+
+// Options configures the report of a package.
+type Options struct {
+	// Dir is the directory of the reports. It defaults to EnvDir, or to DefaultDir at the root of the module.
+	Dir string
+
+	// Package is the import path of the package, which names its report. It defaults to the package of TestMain.
+	Package string
+
+	// Environment describes the environment of the tests in the report.
+	Environment *ctrf.Environment
+
+	// Stdout receives the output of the tests, os.Stdout by default.
+	Stdout io.Writer
+}
+
+// Run runs the tests, writes their report, and returns the exit code of the tests, for os.Exit.
+//
+// The output of the tests is forwarded like go test would, i.e. all of it with -v, and only the output
+// of the failed tests otherwise. Failing to write the report fails the run.
+func Run(m *testing.M, opts Options) int {
+	if os.Getenv(envChild) != "" {
+		return m.Run()
+	}
+
+	if opts.Package == "" {
+		opts.Package = callerPackage()
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+
+	code, err := run(os.Args[0], os.Args[1:], opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-ctrf-json-reporter: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+
+	return code
+}
+
+func run(binary string, args []string, opts Options) (int, error) {
+	jsonMode := hasJSONMode(runtime.Version())
+	verbose, childArgs := childArguments(args, jsonMode)
+
+	var events bytes.Buffer
+	converter := NewConverter(&events, opts.Package)
+	var stdout io.Writer = &unframedWriter{w: opts.Stdout}
+	if !jsonMode {
+		converter = NewPlainConverter(&events, opts.Package)
+		stdout = opts.Stdout
+	}
+	child := exec.Command(binary, childArgs...) //nolint:gosec // the test binary runs itself
+	child.Env = append(os.Environ(), envChild+"=1")
+	child.Stdout = converter
+	if verbose {
+		child.Stdout = io.MultiWriter(converter, stdout)
+	}
+	child.Stderr = child.Stdout
+
+	start := time.Now()
+	code := 0
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return 1, fmt.Errorf("error running the tests: %w", err)
+		}
+		code = exitErr.ExitCode()
+	}
+	if err := converter.Close(code == 0, time.Since(start)); err != nil {
+		return code, err
+	}
+
+	report, err := reporter.ParseTestResults(&events, false, opts.Environment)
+	if err != nil {
+		return code, fmt.Errorf("error parsing test results: %w", err)
+	}
+
+	if !verbose {
+		printFailures(opts.Stdout, report, code == 0)
+	}
+
+	dir, err := reportDir(opts.Dir)
+	if err != nil {
+		return code, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return code, fmt.Errorf("error writing ctrf json report: %w", err)
+	}
+
+	return code, report.WriteFile(filepath.Join(dir, ReportName(opts.Package)))
+}
+
+// ReportName is the name of the report file of a package.
+func ReportName(pkg string) string {
+	return strings.NewReplacer("/", "_", `\`, "_", ":", "_").Replace(pkg) + ".json"
+}
+
+// hasJSONMode tells if the test binaries built by a version of Go, e.g. "go1.20.3", support the JSON mode
+// of their output, i.e. -test.v=test2json (Go 1.20 or newer). Development versions are assumed to support it.
+func hasJSONMode(version string) bool {
+	if !strings.HasPrefix(version, "go1.") {
+		return true
+	}
+
+	minor := strings.TrimPrefix(version, "go1.")
+	if end := strings.IndexFunc(minor, func(r rune) bool { return r < '0' || r > '9' }); end >= 0 {
+		minor = minor[:end]
+	}
+	n, err := strconv.Atoi(minor)
+
+	return err != nil || n >= 20
+}
+
+// childArguments turns the arguments of the test binary into the arguments of the child process,
+// in the JSON mode, or else in verbose mode, and tells if the tests were run in verbose mode.
+func childArguments(args []string, jsonMode bool) (bool, []string) {
+	verbose := false
+	childArgs := make([]string, 0, len(args)+1)
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && (name == "test.v" || name == "v") {
+			verbose = !hasValue || value == "true" || value == "test2json"
+
+			continue
+		}
+		childArgs = append(childArgs, arg)
+	}
+
+	if !jsonMode {
+		return verbose, append(childArgs, "-test.v=true")
+	}
+
+	return verbose, append(childArgs, "-test.v=test2json")
+}
+
+// Markers of the output of a test binary in the JSON mode.
+const (
+	markerFrame       = '\x16'
+	markerErrorStart  = '\x0f'
+	markerErrorFinish = '\x0e'
+	markerEscape      = '\x1b' // escapes a marker, or itself, in the output of the tests
+)
+
+// Converter converts the output of a test binary run with -test.v=test2json into the events of "go test -json",
+// like "go tool test2json" does. The events are timed as the output is written.
+type Converter struct {
+	encoder *json.Encoder
+	pkg     string
+	plain   bool // the output is the one of -test.v, without markers
+	current string
+	partial []byte
+	inError bool // within an error reported by a test, which continues on the next line
+}
+
+// NewConverter returns a converter that writes the events of the tests of a package to w.
+func NewConverter(w io.Writer, pkg string) *Converter {
+	return &Converter{encoder: json.NewEncoder(w), pkg: pkg}
+}
+
+// NewPlainConverter returns a converter of the output of a test binary run with -test.v, for the Go versions
+// without the JSON mode (before Go 1.20). As with "go tool test2json" on these versions, the framing lines
+// are recognized by their text, and the errors reported by the tests are not typed.
+func NewPlainConverter(w io.Writer, pkg string) *Converter {
+	return &Converter{encoder: json.NewEncoder(w), pkg: pkg, plain: true}
+}
+
+// Write converts the complete lines of output written.
+func (c *Converter) Write(p []byte) (int, error) {
+	c.partial = append(c.partial, p...)
+	for {
+		newline := bytes.IndexByte(c.partial, '\n')
+		if newline < 0 {
+			return len(p), nil
+		}
+
+		line := string(c.partial[:newline])
+		c.partial = c.partial[newline+1:]
+		if err := c.convert(line); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// Close converts the last line of output, and writes the outcome of the package, with its elapsed time.
+func (c *Converter) Close(passed bool, elapsed time.Duration) error {
+	if len(c.partial) > 0 {
+		line := string(c.partial)
+		c.partial = nil
+		if err := c.convert(line); err != nil {
+			return err
+		}
+	}
+
+	action := reporter.ActionPass
+	if !passed {
+		action = reporter.ActionFail
+	}
+
+	return c.emit(reporter.TestEvent{Action: action, Elapsed: elapsed.Seconds()})
+}
+
+func (c *Converter) convert(line string) error {
+	frame, isFrame := c.frame(line)
+	if !isFrame {
+		events := []reporter.TestEvent{{Action: reporter.ActionOutput, Test: c.current, Output: line + "\n"}}
+		if !c.plain {
+			events = c.outputEvents(line)
+		}
+		for _, event := range events {
+			if err := c.emit(event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, event := range frameEvents(frame, &c.current) {
+		if err := c.emit(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// frame returns the framing line of a line of output, without its marker or its indentation, if it is one.
+func (c *Converter) frame(line string) (string, bool) {
+	if !c.plain {
+		if line == "" || line[0] != markerFrame {
+			return "", false
+		}
+
+		return strings.TrimLeft(line[1:], " "), true
+	}
+
+	// As with go test, only the results of the subtests are indented.
+	if line == "PASS" || line == "FAIL" {
+		return line, true
+	}
+	for _, f := range testFrames {
+		if strings.HasPrefix(line, f.prefix) {
+			return line, true
+		}
+	}
+	frame := strings.TrimLeft(line, " ")
+	for _, f := range resultFrames {
+		if strings.HasPrefix(frame, f.prefix) {
+			return frame, true
+		}
+	}
+
+	return "", false
+}
+
+// outputEvents splits a line of output at the markers of the errors reported by the tests, into output events
+// typed like "go test -json" does.
+func (c *Converter) outputEvents(line string) []reporter.TestEvent {
+	var events []reporter.TestEvent
+	outputType := ""
+	if c.inError {
+		outputType = reporter.OutputTypeErrorContinue
+	}
+
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			events = append(events, reporter.TestEvent{Action: reporter.ActionOutput, Test: c.current, Output: text.String(), OutputType: outputType})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case markerEscape:
+			if i+1 < len(line) {
+				i++
+				text.WriteByte(line[i])
+			}
+		case markerErrorStart:
+			flush()
+			c.inError = true
+			outputType = reporter.OutputTypeError
+		case markerErrorFinish:
+			// The end of the line belongs to the error that it ends.
+			if i == len(line)-1 {
+				text.WriteByte('\n')
+				flush()
+				c.inError = false
+
+				return events
+			}
+			flush()
+			c.inError = false
+			outputType = ""
+		default:
+			text.WriteByte(line[i])
+		}
+	}
+	text.WriteByte('\n')
+	flush()
+
+	return events
+}
+
+func (c *Converter) emit(event reporter.TestEvent) error {
+	event.Package = c.pkg
+	event.Time = time.Now().Format(time.RFC3339Nano)
+
+	return c.encoder.Encode(event)
+}
+
+// testFrames are the framing lines that start, pause or resume a test, and the action of their event.
+// The output that follows a "=== NAME" line belongs to the test named, without an event of its own.
+var testFrames = []struct{ prefix, action string }{
+	{"=== RUN", reporter.ActionRun},
+	{"=== PAUSE", "pause"},
+	{"=== CONT", "cont"},
+	{"=== NAME", ""},
+}
+
+// resultFrames are the framing lines of the results of the tests, and the action of their event.
+var resultFrames = []struct{ prefix, action string }{
+	{"--- PASS: ", reporter.ActionPass},
+	{"--- FAIL: ", reporter.ActionFail},
+	{"--- SKIP: ", reporter.ActionSkip},
+}
+
+// frameEvents returns the events of a framing line, and updates the test that the next output belongs to.
+func frameEvents(frame string, current *string) []reporter.TestEvent {
+	output := reporter.TestEvent{Action: reporter.ActionOutput, Output: frame + "\n", OutputType: reporter.OutputTypeFrame}
+
+	for _, f := range testFrames {
+		if !strings.HasPrefix(frame, f.prefix) {
+			continue
+		}
+
+		*current = strings.TrimSpace(strings.TrimPrefix(frame, f.prefix))
+		if f.action == "" {
+			return nil
+		}
+		output.Test = *current
+
+		return []reporter.TestEvent{{Action: f.action, Test: *current}, output}
+	}
+
+	for _, f := range resultFrames {
+		if !strings.HasPrefix(frame, f.prefix) {
+			continue
+		}
+
+		test, elapsed := parseResult(strings.TrimPrefix(frame, f.prefix))
+		output.Test = test
+		*current = test
+
+		return []reporter.TestEvent{output, {Action: f.action, Test: test, Elapsed: elapsed}}
+	}
+
+	// The final PASS or FAIL of the package, or another line of the testing package.
+	output.Test = *current
+
+	return []reporter.TestEvent{output}
+}
+
+// parseResult parses the test and the elapsed time of a result line, e.g. "TestA/sub (0.01s)".
+func parseResult(result string) (string, float64) {
+	paren := strings.LastIndex(result, " (")
+	if paren < 0 || !strings.HasSuffix(result, "s)") {
+		return strings.TrimSpace(result), 0
+	}
+
+	elapsed, err := time.ParseDuration(result[paren+2 : len(result)-1])
+	if err != nil {
+		return strings.TrimSpace(result), 0
+	}
+
+	return result[:paren], elapsed.Seconds()
+}
+
+// unframedWriter strips the markers of the JSON mode from the output of the tests.
+type unframedWriter struct {
+	w       io.Writer
+	escaped bool // the last byte written escapes the next one
+}
+
+func (u *unframedWriter) Write(p []byte) (int, error) {
+	unframed := make([]byte, 0, len(p))
+	for _, b := range p {
+		if u.escaped {
+			u.escaped = false
+			unframed = append(unframed, b)
+
+			continue
+		}
+
+		switch b {
+		case markerEscape:
+			u.escaped = true
+		case markerFrame, markerErrorStart, markerErrorFinish:
+		default:
+			unframed = append(unframed, b)
+		}
+	}
+
+	if _, err := u.w.Write(unframed); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// printFailures prints the output of the failed tests, and the outcome of the package, like go test without -v.
+func printFailures(w io.Writer, report *ctrf.Report, passed bool) {
+	for _, test := range report.Results.Tests {
+		if test.Status == ctrf.TestFailed {
+			fmt.Fprint(w, test.Message)
+		}
+	}
+
+	if passed {
+		fmt.Fprintln(w, "PASS")
+	} else {
+		fmt.Fprintln(w, "FAIL")
+	}
+}
+
+// reportDir returns the directory of the reports.
+func reportDir(dir string) (string, error) {
+	if dir == "" {
+		dir = os.Getenv(EnvDir)
+	}
+	if dir != "" {
+		return dir, nil
+	}
+
+	root, err := moduleRoot()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(root, DefaultDir), nil
+}
+
+// moduleRoot finds the root of the module of the package under test, which runs in the directory of the package.
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("error looking up the module root: %w", err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("error looking up the module root: no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// callerPackage returns the import path of the package of the TestMain that calls Run.
+func callerPackage() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+
+	name := runtime.FuncForPC(pc).Name()
+	// The name of a function is its import path, then its name after the last dot of the last element of the path,
+	// e.g. "example.com/a.b/pkg_test.TestMain".
+	slash := strings.LastIndex(name, "/")
+	if dot := strings.Index(name[slash+1:], "."); dot >= 0 {
+		name = name[:slash+1+dot]
+	}
+
+	return strings.TrimSuffix(name, "_test")
+}
@@ -0,0 +1,185 @@
+package gotest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/gotest"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConverter(t *testing.T) {
+	const output = "\x16=== RUN   TestA\n" +
+		"    a_test.go:6: hello\n" +
+		"\x16=== RUN   TestA/sub\n" +
+		"    a_test.go:7: === RUN fake\n" +
+		"\x16--- PASS: TestA/sub (0.00s)\n" +
+		"\x16=== NAME  TestA\n" +
+		"\x16--- PASS: TestA (0.25s)\n" +
+		"\x16=== NAME  \n" +
+		"\x16=== RUN   TestP\n" +
+		"\x16=== PAUSE TestP\n" +
+		"\x16=== CONT  TestP\n" +
+		"\x0f    a_test.go:13: boom\x0e\n" +
+		"\x0f    a_test.go:14: two\n" +
+		"        lines\x0e\n" +
+		"    \x1b\x16escaped\x1b\x1b\n" +
+		"\x16--- FAIL: TestP (0.01s)\n" +
+		"\x16FAIL"
+
+	var b bytes.Buffer
+	converter := gotest.NewConverter(&b, "example.com/pkg")
+	// Lines may be split across writes.
+	for _, chunk := range []string{output[:10], output[10:100], output[100:]} {
+		_, err := converter.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	require.NoError(t, converter.Close(false, 1500*time.Millisecond))
+
+	var events []reporter.TestEvent
+	decoder := json.NewDecoder(&b)
+	for {
+		var event reporter.TestEvent
+		if err := decoder.Decode(&event); errors.Is(err, io.EOF) {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		assert.Equal(t, "example.com/pkg", event.Package)
+		assert.NotEmpty(t, event.Time)
+		event.Package, event.Time = "", ""
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []reporter.TestEvent{
+		{Action: "run", Test: "TestA"},
+		{Action: "output", Test: "TestA", Output: "=== RUN   TestA\n", OutputType: "frame"},
+		{Action: "output", Test: "TestA", Output: "    a_test.go:6: hello\n"},
+		{Action: "run", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA/sub", Output: "=== RUN   TestA/sub\n", OutputType: "frame"},
+		{Action: "output", Test: "TestA/sub", Output: "    a_test.go:7: === RUN fake\n"},
+		{Action: "output", Test: "TestA/sub", Output: "--- PASS: TestA/sub (0.00s)\n", OutputType: "frame"},
+		{Action: "pass", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA", Output: "--- PASS: TestA (0.25s)\n", OutputType: "frame"},
+		{Action: "pass", Test: "TestA", Elapsed: 0.25},
+		{Action: "run", Test: "TestP"},
+		{Action: "output", Test: "TestP", Output: "=== RUN   TestP\n", OutputType: "frame"},
+		{Action: "pause", Test: "TestP"},
+		{Action: "output", Test: "TestP", Output: "=== PAUSE TestP\n", OutputType: "frame"},
+		{Action: "cont", Test: "TestP"},
+		{Action: "output", Test: "TestP", Output: "=== CONT  TestP\n", OutputType: "frame"},
+		{Action: "output", Test: "TestP", Output: "    a_test.go:13: boom\n", OutputType: "error"},
+		{Action: "output", Test: "TestP", Output: "    a_test.go:14: two\n", OutputType: "error"},
+		{Action: "output", Test: "TestP", Output: "        lines\n", OutputType: "error-continue"},
+		{Action: "output", Test: "TestP", Output: "    \x16escaped\x1b\n"},
+		{Action: "output", Test: "TestP", Output: "--- FAIL: TestP (0.01s)\n", OutputType: "frame"},
+		{Action: "fail", Test: "TestP", Elapsed: 0.01},
+		{Action: "output", Test: "TestP", Output: "FAIL\n", OutputType: "frame"},
+		{Action: "fail", Elapsed: 1.5},
+	}, events)
+}
+
+func TestPlainConverter(t *testing.T) {
+	const output = "=== RUN   TestA\n" +
+		"    a_test.go:6: === RUN fake\n" +
+		"=== RUN   TestA/sub\n" +
+		"    --- PASS: TestA/sub (0.00s)\n" +
+		"--- FAIL: TestA (0.25s)\n" +
+		"    a_test.go:13: \x1b[31mboom\x1b[0m\n" +
+		"FAIL\n"
+
+	var b bytes.Buffer
+	converter := gotest.NewPlainConverter(&b, "example.com/pkg")
+	_, err := converter.Write([]byte(output))
+	require.NoError(t, err)
+	require.NoError(t, converter.Close(false, time.Second))
+
+	var events []reporter.TestEvent
+	decoder := json.NewDecoder(&b)
+	for {
+		var event reporter.TestEvent
+		if err := decoder.Decode(&event); errors.Is(err, io.EOF) {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		event.Package, event.Time = "", ""
+		events = append(events, event)
+	}
+
+	assert.Equal(t, []reporter.TestEvent{
+		{Action: "run", Test: "TestA"},
+		{Action: "output", Test: "TestA", Output: "=== RUN   TestA\n", OutputType: "frame"},
+		{Action: "output", Test: "TestA", Output: "    a_test.go:6: === RUN fake\n"},
+		{Action: "run", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA/sub", Output: "=== RUN   TestA/sub\n", OutputType: "frame"},
+		{Action: "output", Test: "TestA/sub", Output: "--- PASS: TestA/sub (0.00s)\n", OutputType: "frame"},
+		{Action: "pass", Test: "TestA/sub"},
+		{Action: "output", Test: "TestA", Output: "--- FAIL: TestA (0.25s)\n", OutputType: "frame"},
+		{Action: "fail", Test: "TestA", Elapsed: 0.25},
+		{Action: "output", Test: "TestA", Output: "    a_test.go:13: \x1b[31mboom\x1b[0m\n"},
+		{Action: "output", Test: "TestA", Output: "FAIL\n", OutputType: "frame"},
+		{Action: "fail", Elapsed: 1},
+	}, events)
+}
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs a test binary")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("requires the go tool")
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(goTool, "test", "-count=1", "./testdata/example")
+	cmd.Env = append(os.Environ(), gotest.EnvDir+"="+dir, "EXAMPLE_FAIL=1")
+	output, err := cmd.CombinedOutput()
+	require.Error(t, err, "the example fails")
+	assert.Contains(t, string(output), "example_test.go:21: boom")
+	assert.NotContains(t, string(output), "looks like a frame", "the output of passed tests is hidden without -v")
+
+	const pkg = "github.com/ctrf-io/go-ctrf-json-reporter/ctrf/gotest/testdata/example"
+	report, err := ctrf.ReadFile(filepath.Join(dir, gotest.ReportName(pkg)))
+	require.NoError(t, err)
+
+	summary := report.Results.Summary
+	assert.Equal(t, 4, summary.Tests)
+	assert.Equal(t, 2, summary.Passed)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 1, summary.Skipped)
+	for _, test := range report.Results.Tests {
+		assert.Equal(t, []string{pkg}, test.Suite)
+		// Before Go 1.20, the errors of the tests are not told apart from their output, nor their line.
+		if test.Status == ctrf.TestFailed && !strings.HasPrefix(runtime.Version(), "go1.19") {
+			assert.Equal(t, 21, test.Line, "the line of the error")
+		}
+	}
+
+	t.Run("should write a valid report without tests", func(t *testing.T) {
+		dir := t.TempDir()
+		cmd := exec.Command(goTool, "test", "-count=1", "-run", "^$", "./testdata/example")
+		cmd.Env = append(os.Environ(), gotest.EnvDir+"="+dir)
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+
+		report, err := ctrf.ReadFile(filepath.Join(dir, gotest.ReportName(pkg)))
+		require.NoError(t, err)
+		assert.Empty(t, report.Validate())
+		assert.Empty(t, report.Results.Tests)
+		assert.Equal(t, 0, report.Results.Summary.Tests)
+	})
+}
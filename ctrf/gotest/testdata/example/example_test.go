@@ -0,0 +1,27 @@
+package example_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/gotest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(gotest.Run(m, gotest.Options{}))
+}
+
+func TestPass(t *testing.T) {
+	t.Log("=== RUN looks like a frame")
+	t.Run("sub", func(t *testing.T) {})
+}
+
+func TestFail(t *testing.T) {
+	if os.Getenv("EXAMPLE_FAIL") != "" {
+		t.Error("boom")
+	}
+}
+
+func TestSkip(t *testing.T) {
+	t.Skip("later")
+}
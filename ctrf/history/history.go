@@ -0,0 +1,259 @@
+// Package history stores the outcome of past test runs, to detect flaky tests across runs.
+//
+// The store is an append-only NDJSON file in a directory: each line records a run, as ingested from a CTRF report.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// runsFile is the name of the file that holds the runs, in the directory of the store.
+const runsFile = "runs.ndjson"
+
+// maxLineSize is the maximum size of a run record.
+const maxLineSize = 64 * 1024 * 1024
+
+// Store is an append-only history of test runs, kept in a directory.
+type Store struct {
+	dir string
+}
+
+// Run is the record of a test run.
+type Run struct {
+	ReportID  string    `json:"reportId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Tests     []TestRun `json:"tests"`
+}
+
+// TestRun is the outcome of a test in a run.
+type TestRun struct {
+	Suite    []string        `json:"suite,omitempty"`
+	Name     string          `json:"name"`
+	Status   ctrf.TestStatus `json:"status"`
+	Duration int64           `json:"duration"`
+	Flaky    bool            `json:"flaky,omitempty"` // the test was flaky within the run, e.g. it passed when retried
+}
+
+// TestStats are the statistics of a test across runs.
+type TestStats struct {
+	Suite    []string `json:"suite,omitempty"`
+	Name     string   `json:"name"`
+	Runs     int      `json:"runs"`     // the number of runs in which the test passed or failed
+	Failures int      `json:"failures"` // the number of runs in which the test failed
+	Flips    int      `json:"flips"`    // the number of times the outcome changed from one run to the next
+	FlipRate float64  `json:"flipRate"` // flips per pair of consecutive runs, from 0 (stable) to 1 (changes every run)
+}
+
+// Open opens the store kept in dir, creating the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error opening history store: %w", err)
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// OpenExisting opens the store kept in dir, to read it: unlike Open, it fails when the directory does not exist.
+func OpenExisting(dir string) (*Store, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error opening history store: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("error opening history store: %s is not a directory", dir)
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// NewRun records the outcome of the tests of a report.
+func NewRun(report *ctrf.Report) Run {
+	run := Run{
+		ReportID:  report.ReportId,
+		Timestamp: report.Timestamp,
+		Tests:     make([]TestRun, 0, len(report.Results.Tests)),
+	}
+
+	for _, test := range report.Results.Tests {
+		run.Tests = append(run.Tests, TestRun{
+			Suite:    test.Suite,
+			Name:     test.Name,
+			Status:   test.Status,
+			Duration: test.Duration,
+			Flaky:    test.Flaky,
+		})
+	}
+
+	return run
+}
+
+// Ingest appends the run of a report to the store.
+func (store *Store) Ingest(report *ctrf.Report) error {
+	return store.Append(NewRun(report))
+}
+
+// Append appends a run to the store.
+func (store *Store) Append(run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("error encoding run: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(store.dir, runsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error ingesting run into history: %w", err)
+	}
+
+	if _, err = file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("error ingesting run into history: %w", err)
+	}
+
+	return file.Close()
+}
+
+// Runs returns the last runs in the store, from the oldest to the most recent.
+//
+// A non-positive last returns all the runs.
+func (store *Store) Runs(last int) ([]Run, error) {
+	file, err := os.Open(filepath.Join(store.dir, runsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("error reading history at line %d: %w", lineNumber, err)
+		}
+		runs = append(runs, run)
+
+		if last > 0 && len(runs) > last {
+			runs = runs[1:]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	return runs, nil
+}
+
+// Key identifies a test across runs.
+func Key(suite []string, name string) string {
+	return strings.Join(suite, "/") + "." + name
+}
+
+// Stats computes the statistics of each test across runs, ordered by decreasing flip rate, then by key.
+//
+// Only passed and failed outcomes are taken into account: a skipped test neither flips nor breaks a series.
+// A test that was flaky within a run flips twice, as it both failed and passed.
+func Stats(runs []Run) []*TestStats {
+	byKey := make(map[string]*TestStats)
+	lastStatus := make(map[string]ctrf.TestStatus)
+
+	for _, run := range runs {
+		for _, test := range run.Tests {
+			if test.Status != ctrf.TestPassed && test.Status != ctrf.TestFailed {
+				continue
+			}
+
+			key := Key(test.Suite, test.Name)
+			stats, ok := byKey[key]
+			if !ok {
+				stats = &TestStats{Suite: test.Suite, Name: test.Name}
+				byKey[key] = stats
+			}
+
+			stats.Runs++
+			if test.Status == ctrf.TestFailed {
+				stats.Failures++
+			}
+			if previous, ok := lastStatus[key]; ok && previous != test.Status {
+				stats.Flips++
+			}
+			if test.Flaky {
+				stats.Flips += 2
+			}
+			lastStatus[key] = test.Status
+		}
+	}
+
+	all := make([]*TestStats, 0, len(byKey))
+	for _, stats := range byKey {
+		if stats.Runs > 1 {
+			stats.FlipRate = float64(stats.Flips) / float64(stats.Runs-1)
+		}
+		if stats.FlipRate > 1 {
+			stats.FlipRate = 1
+		}
+		all = append(all, stats)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].FlipRate != all[j].FlipRate {
+			return all[i].FlipRate > all[j].FlipRate
+		}
+
+		return Key(all[i].Suite, all[i].Name) < Key(all[j].Suite, all[j].Name)
+	})
+
+	return all
+}
+
+// IsFlaky tells if the history of a test shows that it is flaky: its outcome changed at least twice
+// (e.g. passed, failed, then passed again), at a flip rate of at least threshold.
+func (stats *TestStats) IsFlaky(threshold float64) bool {
+	return stats.Flips >= 2 && stats.FlipRate >= threshold
+}
+
+// MarkFlaky marks the tests of a report as flaky when their history shows they are (see TestStats.IsFlaky),
+// and returns their statistics, by test.
+//
+// The tests newly marked as flaky are counted in the flaky tests of the summary, so that the report stays consistent.
+func MarkFlaky(report *ctrf.Report, stats []*TestStats, threshold float64) map[*ctrf.TestResult]*TestStats {
+	byKey := make(map[string]*TestStats, len(stats))
+	for _, s := range stats {
+		byKey[Key(s.Suite, s.Name)] = s
+	}
+
+	flaky := make(map[*ctrf.TestResult]*TestStats)
+	for _, test := range report.Results.Tests {
+		s, ok := byKey[Key(test.Suite, test.Name)]
+		if !ok || !s.IsFlaky(threshold) {
+			continue
+		}
+
+		if !test.Flaky {
+			report.Results.Summary.Flaky++
+		}
+		test.Flaky = true
+		flaky[test] = s
+	}
+
+	return flaky
+}
@@ -0,0 +1,110 @@
+package history_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "history"))
+	require.NoError(t, err)
+
+	runs, err := store.Runs(0)
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	for _, statuses := range [][]ctrf.TestStatus{
+		{ctrf.TestPassed, ctrf.TestPassed, ctrf.TestPassed},
+		{ctrf.TestFailed, ctrf.TestPassed, ctrf.TestSkipped},
+		{ctrf.TestPassed, ctrf.TestFailed, ctrf.TestPassed},
+		{ctrf.TestFailed, ctrf.TestFailed, ctrf.TestPassed},
+	} {
+		require.NoError(t, store.Ingest(historyReport(statuses...)))
+	}
+
+	runs, err = store.Runs(0)
+	require.NoError(t, err)
+	require.Len(t, runs, 4)
+
+	runs, err = store.Runs(3)
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	assert.Equal(t, ctrf.TestFailed, runs[0].Tests[0].Status)
+
+	t.Run("should compute flip rates", func(t *testing.T) {
+		stats := history.Stats(runs)
+
+		assert.Equal(t, []*history.TestStats{
+			{Suite: []string{"pkg"}, Name: "TestA", Runs: 3, Failures: 2, Flips: 2, FlipRate: 1},
+			{Suite: []string{"pkg"}, Name: "TestB", Runs: 3, Failures: 2, Flips: 1, FlipRate: 0.5},
+			{Suite: []string{"pkg"}, Name: "TestC", Runs: 2, Failures: 0, Flips: 0, FlipRate: 0},
+		}, stats)
+	})
+
+	t.Run("should mark flaky tests in a new report", func(t *testing.T) {
+		report := historyReport(ctrf.TestPassed, ctrf.TestPassed, ctrf.TestPassed)
+
+		flaky := history.MarkFlaky(report, history.Stats(runs), 0.5)
+
+		require.Len(t, flaky, 1)
+		assert.Equal(t, "TestA", flaky[report.Results.Tests[0]].Name)
+		assert.Equal(t, 1, report.Results.Summary.Flaky, "the summary counts the flaky tests")
+		assert.True(t, report.Results.Tests[0].Flaky)
+		assert.False(t, report.Results.Tests[1].Flaky, "a single flip is not enough to be flaky")
+		assert.False(t, report.Results.Tests[2].Flaky)
+		assert.Equal(t, ctrf.TestPassed, report.Results.Tests[0].Status)
+	})
+
+	t.Run("should count flakiness within a run", func(t *testing.T) {
+		stats := history.Stats([]history.Run{{Tests: []history.TestRun{
+			{Suite: []string{"pkg"}, Name: "TestA", Status: ctrf.TestPassed, Flaky: true},
+		}}, {Tests: []history.TestRun{
+			{Suite: []string{"pkg"}, Name: "TestA", Status: ctrf.TestPassed},
+		}}})
+
+		assert.Equal(t, 2, stats[0].Flips)
+		assert.InDelta(t, 1.0, stats[0].FlipRate, 0)
+	})
+
+	t.Run("should reject a corrupted history", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "runs.ndjson"), []byte("{}\nnot json\n"), 0o600))
+		corrupted, err := history.Open(dir)
+		require.NoError(t, err)
+
+		_, err = corrupted.Runs(0)
+		require.ErrorContains(t, err, "error reading history at line 2")
+	})
+
+	t.Run("should only open an existing store to read it", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "missing")
+		_, err := history.OpenExisting(missing)
+		require.ErrorContains(t, err, "error opening history store")
+		assert.NoDirExists(t, missing)
+
+		existing, err := history.OpenExisting(filepath.Dir(missing))
+		require.NoError(t, err)
+		runs, err := existing.Runs(0)
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+	})
+}
+
+func historyReport(statuses ...ctrf.TestStatus) *ctrf.Report {
+	report := ctrf.NewReport("gotest", nil)
+	for i, status := range statuses {
+		report.Results.Tests = append(report.Results.Tests, &ctrf.TestResult{
+			Suite:  []string{"pkg"},
+			Name:   "Test" + string(rune('A'+i)),
+			Status: status,
+		})
+	}
+
+	return report
+}
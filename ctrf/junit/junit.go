@@ -0,0 +1,446 @@
+// Package junit converts JUnit XML reports into CTRF reports.
+//
+// It reads the common dialects of JUnit XML: the reports of Ant and Gradle, the Maven Surefire reports
+// with their reruns of flaky tests, and the reports of jest-junit and pytest.
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// ToolName is the tool of the converted reports.
+const ToolName = "junit"
+
+// Raw statuses of the failed tests, as named by JUnit.
+const (
+	RawStatusFailure = "failure"
+	RawStatusError   = "error"
+)
+
+type xmlTestSuites struct {
+	Properties []xmlProperty  `xml:"properties>property"`
+	Suites     []xmlTestSuite `xml:"testsuite"`
+}
+
+type xmlTestSuite struct {
+	Name       string         `xml:"name,attr"`
+	Timestamp  string         `xml:"timestamp,attr"`
+	Time       string         `xml:"time,attr"`
+	File       string         `xml:"file,attr"`
+	Properties []xmlProperty  `xml:"properties>property"`
+	Suites     []xmlTestSuite `xml:"testsuite"`
+	Cases      []xmlTestCase  `xml:"testcase"`
+}
+
+type xmlProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+type xmlTestCase struct {
+	Name          string       `xml:"name,attr"`
+	Classname     string       `xml:"classname,attr"`
+	Time          string       `xml:"time,attr"`
+	File          string       `xml:"file,attr"`
+	Failures      []xmlProblem `xml:"failure"`
+	Errors        []xmlProblem `xml:"error"`
+	Skipped       *xmlProblem  `xml:"skipped"`
+	FlakyFailures []xmlProblem `xml:"flakyFailure"`
+	FlakyErrors   []xmlProblem `xml:"flakyError"`
+	RerunFailures []xmlProblem `xml:"rerunFailure"`
+	RerunErrors   []xmlProblem `xml:"rerunError"`
+	SystemOut     []string     `xml:"system-out"`
+	SystemErr     []string     `xml:"system-err"`
+}
+
+// xmlProblem is a failure, an error or a skip, or a Surefire rerun.
+type xmlProblem struct {
+	Message   string `xml:"message,attr"`
+	Time      string `xml:"time,attr"`
+	Text      string `xml:",chardata"`
+	SystemOut string `xml:"system-out"`
+	SystemErr string `xml:"system-err"`
+}
+
+// Read converts a JUnit XML report, whose root is either a <testsuites> or a <testsuite> element.
+func Read(r io.Reader) (*ctrf.Report, error) {
+	c := newConverter()
+	if err := c.read(r); err != nil {
+		return nil, err
+	}
+
+	return c.report(), nil
+}
+
+// ReadFiles converts JUnit XML report files into a single report.
+func ReadFiles(filenames ...string) (*ctrf.Report, error) {
+	c := newConverter()
+	for _, filename := range filenames {
+		if err := c.readFile(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.report(), nil
+}
+
+// converter accumulates the test suites of JUnit XML reports.
+type converter struct {
+	summary     ctrf.Summary
+	tests       []*ctrf.TestResult
+	environment *ctrf.Environment
+}
+
+func newConverter() *converter {
+	return &converter{tests: []*ctrf.TestResult{}}
+}
+
+func (c *converter) readFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("error reading junit report: %w", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	if err := c.read(file); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return nil
+}
+
+func (c *converter) read(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("error reading junit report: no test suites")
+			}
+
+			return fmt.Errorf("error reading junit report: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "testsuites":
+			var suites xmlTestSuites
+			if err := decoder.DecodeElement(&suites, &start); err != nil {
+				return fmt.Errorf("error reading junit report: %w", err)
+			}
+			if err := c.addProperties(suites.Properties); err != nil {
+				return err
+			}
+			for i := range suites.Suites {
+				if err := c.addSuite(&suites.Suites[i], nil); err != nil {
+					return err
+				}
+			}
+		case "testsuite":
+			var suite xmlTestSuite
+			if err := decoder.DecodeElement(&suite, &start); err != nil {
+				return fmt.Errorf("error reading junit report: %w", err)
+			}
+			if err := c.addSuite(&suite, nil); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("error reading junit report: unexpected root element <%s>", start.Name.Local)
+		}
+
+		return nil
+	}
+}
+
+func (c *converter) addSuite(suite *xmlTestSuite, parents []string) error {
+	c.summary.Suites++
+	if err := c.addProperties(suite.Properties); err != nil {
+		return err
+	}
+
+	path := parents
+	if suite.Name != "" {
+		path = append(append([]string{}, parents...), suite.Name)
+	}
+
+	if start, ok := parseTimestamp(suite.Timestamp); ok {
+		stop := start + parseDuration(suite.Time)
+		if c.summary.Start == 0 || start < c.summary.Start {
+			c.summary.Start = start
+		}
+		if stop > c.summary.Stop {
+			c.summary.Stop = stop
+		}
+	}
+
+	for i := range suite.Cases {
+		c.addTest(convertTestCase(&suite.Cases[i], path, suite.File))
+	}
+	for i := range suite.Suites {
+		if err := c.addSuite(&suite.Suites[i], path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *converter) addTest(test *ctrf.TestResult) {
+	c.summary.Tests++
+	if test.Flaky {
+		c.summary.Flaky++
+	}
+
+	switch test.Status {
+	case ctrf.TestPassed:
+		c.summary.Passed++
+	case ctrf.TestFailed:
+		c.summary.Failed++
+	case ctrf.TestSkipped:
+		c.summary.Skipped++
+	default:
+		c.summary.Other++
+	}
+
+	c.tests = append(c.tests, test)
+}
+
+// addProperties maps the properties of the reports to the environment.
+//
+// The properties named after a field of the environment set it, e.g. "appName", "app.name" or "APP_NAME",
+// as well as the "os.name" and "os.version" system properties reported by Java.
+// The other properties are kept in the extra field of the environment. The first value of a property wins.
+func (c *converter) addProperties(properties []xmlProperty) error {
+	for _, property := range properties {
+		if property.Name == "" {
+			continue
+		}
+		if c.environment == nil {
+			c.environment = &ctrf.Environment{}
+		}
+
+		value := property.Value
+		if value == "" {
+			value = strings.TrimSpace(property.Text)
+		}
+
+		field := environmentField(c.environment, property.Name)
+		if field == nil {
+			if _, exists := ctrf.GetExtra[any](c.environment.Extra, property.Name); !exists {
+				if err := ctrf.SetExtra(&c.environment.Extra, property.Name, value); err != nil {
+					return fmt.Errorf("error reading junit report: %w", err)
+				}
+			}
+
+			continue
+		}
+		if *field == "" {
+			*field = value
+		}
+	}
+
+	return nil
+}
+
+func environmentField(env *ctrf.Environment, name string) *string {
+	normalized := strings.NewReplacer(".", "", "_", "", "-", "").Replace(strings.ToLower(name))
+	switch normalized {
+	case "appname":
+		return &env.AppName
+	case "appversion":
+		return &env.AppVersion
+	case "osplatform", "osname":
+		return &env.OSPlatform
+	case "osrelease":
+		return &env.OSRelease
+	case "osversion":
+		return &env.OSVersion
+	case "buildname":
+		return &env.BuildName
+	case "buildnumber":
+		return &env.BuildNumber
+	case "buildid":
+		return &env.BuildID
+	case "buildurl":
+		return &env.BuildURL
+	case "reportname":
+		return &env.ReportName
+	case "repositoryname":
+		return &env.RepositoryName
+	case "repositoryurl":
+		return &env.RepositoryURL
+	case "commit":
+		return &env.Commit
+	case "branchname", "branch":
+		return &env.BranchName
+	case "testenvironment":
+		return &env.TestEnvironment
+	default:
+		return nil
+	}
+}
+
+func (c *converter) report() *ctrf.Report {
+	report := ctrf.NewReport(ToolName, c.environment)
+	summary := c.summary
+	summary.Duration = summary.Stop - summary.Start
+	report.Results.Summary = &summary
+	report.Results.Tests = c.tests
+
+	return report
+}
+
+// convertTestCase converts a test case of a suite.
+//
+// The class name of the test case is the last level of its suite, unless it repeats the suite or the test:
+// Surefire names the suites after the classes, and jest-junit names the test cases after their class.
+func convertTestCase(testCase *xmlTestCase, suite []string, suiteFile string) *ctrf.TestResult {
+	if testCase.Classname != "" && testCase.Classname != testCase.Name && (len(suite) == 0 || suite[len(suite)-1] != testCase.Classname) {
+		suite = append(append([]string{}, suite...), testCase.Classname)
+	}
+
+	test := &ctrf.TestResult{
+		Name:     testCase.Name,
+		Status:   ctrf.TestPassed,
+		Duration: parseDuration(testCase.Time),
+		Suite:    suite,
+		Filepath: testCase.File,
+		Stdout:   lines(testCase.SystemOut...),
+		Stderr:   lines(testCase.SystemErr...),
+	}
+	if test.Filepath == "" {
+		test.Filepath = suiteFile
+	}
+
+	switch {
+	case len(testCase.Failures) > 0:
+		setProblem(test, ctrf.TestFailed, RawStatusFailure, &testCase.Failures[0])
+	case len(testCase.Errors) > 0:
+		setProblem(test, ctrf.TestFailed, RawStatusError, &testCase.Errors[0])
+	case testCase.Skipped != nil:
+		setProblem(test, ctrf.TestSkipped, "", testCase.Skipped)
+	}
+
+	// Surefire records the failed runs of a test that passed on a rerun as flaky failures,
+	// and the reruns of a test that kept failing as rerun failures.
+	var reruns []xmlProblem
+	switch test.Status {
+	case ctrf.TestPassed:
+		reruns = append(append(reruns, testCase.FlakyFailures...), testCase.FlakyErrors...)
+		test.Flaky = len(reruns) > 0
+	case ctrf.TestFailed:
+		reruns = append(append(reruns, testCase.RerunFailures...), testCase.RerunErrors...)
+	}
+	if len(reruns) > 0 {
+		setRetryAttempts(test, reruns)
+	}
+
+	return test
+}
+
+func setProblem(test *ctrf.TestResult, status ctrf.TestStatus, rawStatus string, problem *xmlProblem) {
+	test.Status = status
+	test.RawStatus = rawStatus
+	test.Message = problem.Message
+	text := strings.TrimSpace(problem.Text)
+	if test.Message == "" {
+		test.Message = text
+	} else if status == ctrf.TestFailed {
+		test.Trace = text
+	}
+}
+
+// setRetryAttempts records the reruns of a test as retry attempts, with the final run as the last attempt.
+//
+// A failed test that was rerun starts with its first failure, and a flaky test ends with its pass.
+func setRetryAttempts(test *ctrf.TestResult, reruns []xmlProblem) {
+	var attempts []ctrf.RetryAttempt
+	if test.Status == ctrf.TestFailed {
+		attempts = append(attempts, ctrf.RetryAttempt{
+			Status:  ctrf.TestFailed,
+			Message: test.Message,
+			Trace:   test.Trace,
+		})
+	}
+	for _, rerun := range reruns {
+		attempt := ctrf.RetryAttempt{
+			Status:   ctrf.TestFailed,
+			Message:  rerun.Message,
+			Duration: parseDuration(rerun.Time),
+			Stdout:   lines(rerun.SystemOut),
+			Stderr:   lines(rerun.SystemErr),
+		}
+		text := strings.TrimSpace(rerun.Text)
+		if attempt.Message == "" {
+			attempt.Message = text
+		} else {
+			attempt.Trace = text
+		}
+		attempts = append(attempts, attempt)
+	}
+	if test.Status == ctrf.TestPassed {
+		attempts = append(attempts, ctrf.RetryAttempt{Status: ctrf.TestPassed, Duration: test.Duration})
+	}
+
+	for i := range attempts {
+		attempts[i].Attempt = i + 1
+	}
+	test.RetryAttempts = attempts
+	test.Retries = len(attempts)
+}
+
+// parseDuration parses a duration in seconds, e.g. "1.5" or "1,234.5", into milliseconds.
+func parseDuration(seconds string) int64 {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(seconds), ",", ""), 64)
+	if err != nil || value < 0 {
+		return 0
+	}
+
+	return int64(math.Round(value * 1000))
+}
+
+// timestampLayouts are the layouts of the timestamps of the test suites: JUnit does not record a time zone,
+// but some tools do.
+var timestampLayouts = []string{"2006-01-02T15:04:05.999999999", time.RFC3339Nano, "2006-01-02 15:04:05"}
+
+// parseTimestamp parses the timestamp of a test suite into milliseconds since the epoch, in UTC when it has no time zone.
+func parseTimestamp(timestamp string) (int64, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(timestamp)); err == nil {
+			return t.UnixMilli(), true
+		}
+	}
+
+	return 0, false
+}
+
+// lines splits outputs into lines.
+func lines(outputs ...string) []string {
+	var all []string
+	for _, output := range outputs {
+		output = strings.TrimRight(output, "\r\n")
+		if strings.TrimSpace(output) == "" {
+			continue
+		}
+		all = append(all, strings.Split(output, "\n")...)
+	}
+
+	return all
+}
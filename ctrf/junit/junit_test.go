@@ -0,0 +1,111 @@
+package junit_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/junit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFiles(t *testing.T) {
+	t.Run("should convert a surefire report", func(t *testing.T) {
+		report, err := junit.ReadFiles(filepath.Join("testdata", "surefire.xml"))
+		require.NoError(t, err)
+		require.Empty(t, report.Validate())
+
+		assert.Equal(t, junit.ToolName, report.Results.Tool.Name)
+		assert.Equal(t, &ctrf.Environment{
+			OSPlatform:  "Linux",
+			OSVersion:   "6.1.0",
+			BuildNumber: "128",
+			Extra:       map[string]any{"java.version": "21.0.2"},
+		}, report.Results.Environment)
+		assert.Equal(t, &ctrf.Summary{
+			Tests: 5, Passed: 2, Failed: 2, Skipped: 1, Flaky: 1, Suites: 1,
+			Start: 1709287200000, Stop: 1709287203250, Duration: 3250,
+		}, report.Results.Summary)
+
+		tests := report.Results.Tests
+		require.Len(t, tests, 5)
+
+		assert.Equal(t, &ctrf.TestResult{
+			Name: "addsItem", Status: ctrf.TestPassed, Duration: 12,
+			Suite:  []string{"com.example.CartTest"},
+			Stdout: []string{"adding item", "done"},
+		}, tests[0])
+
+		assert.Equal(t, ctrf.TestFailed, tests[1].Status)
+		assert.Equal(t, junit.RawStatusFailure, tests[1].RawStatus)
+		assert.Equal(t, int64(1200500), tests[1].Duration)
+		assert.Equal(t, "expected 0 but was 1", tests[1].Message)
+		assert.Contains(t, tests[1].Trace, "CartTest.java:42")
+		assert.Equal(t, 2, tests[1].Retries)
+		assert.Equal(t, []ctrf.RetryAttempt{
+			{Attempt: 1, Status: ctrf.TestFailed, Message: tests[1].Message, Trace: tests[1].Trace},
+			{Attempt: 2, Status: ctrf.TestFailed, Message: "expected 0 but was 2", Trace: "trace", Duration: 500},
+		}, tests[1].RetryAttempts)
+
+		assert.Equal(t, ctrf.TestFailed, tests[2].Status)
+		assert.Equal(t, junit.RawStatusError, tests[2].RawStatus)
+		assert.Equal(t, "connection refused", tests[2].Message)
+
+		assert.Equal(t, ctrf.TestSkipped, tests[3].Status)
+		assert.Equal(t, "not implemented yet", tests[3].Message)
+
+		assert.Equal(t, ctrf.TestPassed, tests[4].Status)
+		assert.True(t, tests[4].Flaky)
+		assert.Equal(t, []ctrf.RetryAttempt{
+			{Attempt: 1, Status: ctrf.TestFailed, Message: "timeout", Duration: 100, Stdout: []string{"retrying"}},
+			{Attempt: 2, Status: ctrf.TestPassed, Duration: 200},
+		}, tests[4].RetryAttempts)
+	})
+
+	t.Run("should convert a jest report", func(t *testing.T) {
+		report, err := junit.ReadFiles(filepath.Join("testdata", "jest.xml"))
+		require.NoError(t, err)
+		require.Empty(t, report.Validate())
+
+		assert.Nil(t, report.Results.Environment)
+		assert.Equal(t, int64(1709287201500), report.Results.Summary.Start)
+		assert.Equal(t, int64(1709287203000), report.Results.Summary.Stop)
+
+		tests := report.Results.Tests
+		require.Len(t, tests, 2)
+		assert.Equal(t, "Cart adds an item", tests[0].Name)
+		assert.Equal(t, []string{"Cart"}, tests[0].Suite)
+		assert.Equal(t, "src/cart.test.js", tests[0].Filepath)
+		assert.Equal(t, ctrf.TestSkipped, tests[1].Status)
+	})
+
+	t.Run("should nest suites and merge reports", func(t *testing.T) {
+		report, err := junit.ReadFiles(filepath.Join("testdata", "nested.xml"), filepath.Join("testdata", "jest.xml"))
+		require.NoError(t, err)
+		require.Empty(t, report.Validate())
+
+		assert.Equal(t, "shop", report.Results.Environment.AppName)
+		assert.Equal(t, 3, report.Results.Summary.Tests)
+		assert.Equal(t, 3, report.Results.Summary.Suites)
+		assert.Equal(t, int64(1709283600000), report.Results.Summary.Start)
+		assert.Equal(t, int64(1709287203000), report.Results.Summary.Stop)
+
+		pays := report.Results.Tests[0]
+		assert.Equal(t, []string{"e2e", "checkout", "tests.checkout.PaymentTest"}, pays.Suite)
+		assert.Equal(t, "tests/checkout.py", pays.Filepath)
+		assert.Equal(t, int64(2000), pays.Duration)
+	})
+
+	t.Run("should reject other documents", func(t *testing.T) {
+		_, err := junit.Read(strings.NewReader(`<html></html>`))
+		require.EqualError(t, err, "error reading junit report: unexpected root element <html>")
+
+		_, err = junit.Read(strings.NewReader(``))
+		require.EqualError(t, err, "error reading junit report: no test suites")
+
+		_, err = junit.ReadFiles(filepath.Join("testdata", "missing.xml"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,112 @@
+package ctrf
+
+import "github.com/google/uuid"
+
+// Merge combines reports, e.g. the reports of the packages of a module, into a new report.
+//
+// The tests of the reports are concatenated, and their summaries added up, from the earliest start
+// to the latest stop. The tool and the environment are the ones of the first report that has them.
+// The extra fields are merged key by key, as JSON, with their lists concatenated, and the first value
+// of a key wins otherwise. Merging no report returns nil.
+func Merge(reports ...*Report) *Report {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	merged := &Report{
+		ReportFormat: ReportFormatCTRF,
+		SpecVersion:  SpecVersionCTRF,
+		ReportId:     uuid.New().String(),
+		Timestamp:    reports[0].Timestamp,
+		GeneratedBy:  GeneratedByDefault,
+		Results: &Results{
+			Summary: &Summary{},
+			Tests:   []*TestResult{},
+		},
+	}
+
+	results := merged.Results
+	for _, report := range reports {
+		if report.Results == nil {
+			continue
+		}
+		if report.Timestamp.After(merged.Timestamp) {
+			merged.Timestamp = report.Timestamp
+		}
+		if results.Tool == nil {
+			results.Tool = report.Results.Tool
+		}
+		if results.Environment == nil {
+			results.Environment = report.Results.Environment
+		}
+
+		results.Tests = append(results.Tests, report.Results.Tests...)
+		results.Extra = mergeExtra(results.Extra, jsonValue(report.Results.Extra))
+		if report.Results.Summary != nil {
+			results.Summary.add(report.Results.Summary)
+		}
+	}
+
+	if results.Tool == nil {
+		results.Tool = &Tool{Name: "gotest"}
+	}
+	results.Summary.Duration = results.Summary.Stop - results.Summary.Start
+
+	return merged
+}
+
+// add adds up the counts of another summary, and extends the period of the summary to the other one.
+func (summary *Summary) add(other *Summary) {
+	if summary.Start == 0 || (other.Start != 0 && other.Start < summary.Start) {
+		summary.Start = other.Start
+	}
+	if other.Stop > summary.Stop {
+		summary.Stop = other.Stop
+	}
+
+	summary.Tests += other.Tests
+	summary.Passed += other.Passed
+	summary.Failed += other.Failed
+	summary.Pending += other.Pending
+	summary.Skipped += other.Skipped
+	summary.Other += other.Other
+	summary.Flaky += other.Flaky
+	summary.Suites += other.Suites
+	summary.Extra = mergeExtra(summary.Extra, jsonValue(other.Extra))
+}
+
+func mergeExtra(extra, other any) any {
+	if extra == nil {
+		return other
+	}
+
+	extraMap, isMap := extra.(map[string]any)
+	otherMap, otherIsMap := other.(map[string]any)
+	if !isMap || !otherIsMap {
+		return extra
+	}
+
+	merged := make(map[string]any, len(extraMap)+len(otherMap))
+	for key, value := range extraMap {
+		merged[key] = value
+	}
+	for key, value := range otherMap {
+		existing, exists := merged[key]
+		if !exists {
+			merged[key] = value
+
+			continue
+		}
+
+		switch existing := existing.(type) {
+		case []any:
+			if values, isList := value.([]any); isList {
+				merged[key] = append(append([]any{}, existing...), values...)
+			}
+		case map[string]any:
+			merged[key] = mergeExtra(existing, value)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,49 @@
+package ctrf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	first := NewReport("gotest", &Environment{AppName: "app"})
+	first.Timestamp = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	first.Results.Summary = &Summary{Tests: 2, Passed: 1, Failed: 1, Start: 2000, Stop: 3000}
+	first.Results.Tests = []*TestResult{{Name: "TestA", Status: TestPassed}, {Name: "TestB", Status: TestFailed}}
+	first.Results.Extra = map[string]any{
+		"packages":        []any{map[string]any{"package": "a"}},
+		"packageCoverage": map[string]any{"a": 50.0},
+	}
+
+	second := NewReport("gotest", nil)
+	second.Timestamp = time.Date(2024, 3, 1, 10, 5, 0, 0, time.UTC)
+	second.Results.Summary = &Summary{Tests: 1, Skipped: 1, Flaky: 0, Start: 1000, Stop: 2500}
+	second.Results.Tests = []*TestResult{{Name: "TestC", Status: TestSkipped}}
+	second.Results.Extra = map[string]any{
+		"packages":        []any{map[string]any{"package": "b"}},
+		"packageCoverage": map[string]any{"b": 75.0},
+		"buildFail":       []any{},
+	}
+
+	merged := Merge(first, second)
+	require.NotNil(t, merged)
+	require.Empty(t, merged.Validate())
+
+	assert.Equal(t, SpecVersionCTRF, merged.SpecVersion)
+	assert.NotEqual(t, first.ReportId, merged.ReportId)
+	assert.Equal(t, second.Timestamp, merged.Timestamp)
+	assert.Equal(t, "gotest", merged.Results.Tool.Name)
+	assert.Equal(t, first.Results.Environment, merged.Results.Environment)
+	assert.Equal(t, &Summary{Tests: 3, Passed: 1, Failed: 1, Skipped: 1, Start: 1000, Stop: 3000, Duration: 2000}, merged.Results.Summary)
+	assert.Len(t, merged.Results.Tests, 3)
+	assert.Equal(t, map[string]any{
+		"packages":        []any{map[string]any{"package": "a"}, map[string]any{"package": "b"}},
+		"packageCoverage": map[string]any{"a": 50.0, "b": 75.0},
+		"buildFail":       []any{},
+	}, merged.Results.Extra)
+
+	assert.Nil(t, Merge())
+}
@@ -0,0 +1,241 @@
+// Package openmetrics exposes the metrics of a CTRF report in the OpenMetrics text format,
+// or in the Prometheus text format to push them to a Pushgateway.
+package openmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// Content types of the formats.
+const (
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	ContentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Format is the text format of the metrics.
+type Format int
+
+const (
+	// OpenMetrics is the OpenMetrics text format: counter samples have a "_total" suffix, and the exposition ends with "# EOF".
+	OpenMetrics Format = iota
+
+	// Text is the Prometheus text format, as expected by a Pushgateway.
+	Text
+)
+
+type metricType string
+
+const (
+	counter metricType = "counter"
+	gauge   metricType = "gauge"
+)
+
+// family is a metric family, i.e. the samples of a metric.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples []sample
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type label struct {
+	name, value string
+}
+
+// Write writes the metrics of a report.
+//
+// The counts of the summary are counters, and the durations of the run and of each test are gauges.
+// Every sample is labeled with the environment of the report.
+func Write(w io.Writer, report *ctrf.Report, format Format) error {
+	var b bytes.Buffer
+	for _, f := range families(report) {
+		name := f.name
+		if f.typ == counter && format == Text {
+			name += "_total"
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, f.help, name, f.typ)
+
+		for _, s := range f.samples {
+			sampleName := f.name
+			if f.typ == counter {
+				sampleName += "_total"
+			}
+			b.WriteString(sampleName)
+			writeLabels(&b, s.labels)
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(s.value, 'f', -1, 64))
+		}
+	}
+	if format == OpenMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+// WriteFile writes the metrics of a report to a file, in the OpenMetrics text format.
+func WriteFile(filename string, report *ctrf.Report) error {
+	var b bytes.Buffer
+	if err := Write(&b, report, OpenMetrics); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+
+	if err := os.WriteFile(filename, b.Bytes(), 0o644); err != nil { //nolint:gosec // the file is meant to be shared
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Push replaces the metrics of a job on a Pushgateway with the metrics of a report.
+//
+// The grouping key of the metrics is the job, and the optional grouping labels.
+func Push(ctx context.Context, client *http.Client, gateway, job string, grouping map[string]string, report *ctrf.Report) error {
+	var body bytes.Buffer
+	if err := Write(&body, report, Text); err != nil {
+		return fmt.Errorf("error pushing metrics: %w", err)
+	}
+
+	pushURL := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	names := make([]string, 0, len(grouping))
+	for name := range grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pushURL += "/" + url.PathEscape(name) + "/" + url.PathEscape(grouping[name])
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPut, pushURL, &body)
+	if err != nil {
+		return fmt.Errorf("error pushing metrics: %w", err)
+	}
+	request.Header.Set("Content-Type", ContentTypeText)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error pushing metrics: %w", err)
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+		return fmt.Errorf("error pushing metrics: %s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+func families(report *ctrf.Report) []*family {
+	results := report.Results
+	summary := results.Summary
+	if summary == nil {
+		summary = &ctrf.Summary{}
+	}
+	env := environmentLabels(results.Environment)
+
+	counters := []struct {
+		name, help string
+		value      int
+	}{
+		{"ctrf_tests", "The number of tests.", summary.Tests},
+		{"ctrf_tests_passed", "The number of passed tests.", summary.Passed},
+		{"ctrf_tests_failed", "The number of failed tests.", summary.Failed},
+		{"ctrf_tests_skipped", "The number of skipped tests.", summary.Skipped},
+		{"ctrf_tests_pending", "The number of pending tests.", summary.Pending},
+		{"ctrf_tests_other", "The number of tests with another status.", summary.Other},
+		{"ctrf_tests_flaky", "The number of flaky tests.", summary.Flaky},
+	}
+
+	all := make([]*family, 0, len(counters)+3)
+	for _, c := range counters {
+		all = append(all, &family{name: c.name, help: c.help, typ: counter, samples: []sample{{labels: env, value: float64(c.value)}}})
+	}
+
+	all = append(all,
+		&family{
+			name: "ctrf_run_start_timestamp_seconds", help: "The time the run started, in seconds since the epoch.", typ: gauge,
+			samples: []sample{{labels: env, value: seconds(summary.Start)}},
+		},
+		&family{
+			name: "ctrf_run_duration_seconds", help: "The duration of the run.", typ: gauge,
+			samples: []sample{{labels: env, value: seconds(summary.Stop - summary.Start)}},
+		},
+	)
+
+	tests := &family{name: "ctrf_test_duration_seconds", help: "The duration of a test.", typ: gauge}
+	for _, test := range results.Tests {
+		labels := append([]label{
+			{"suite", strings.Join(test.Suite, "/")},
+			{"name", test.Name},
+			{"status", string(test.Status)},
+		}, env...)
+		tests.samples = append(tests.samples, sample{labels: labels, value: seconds(test.Duration)})
+	}
+
+	return append(all, tests)
+}
+
+func environmentLabels(env *ctrf.Environment) []label {
+	if env == nil {
+		return nil
+	}
+
+	var labels []label
+	for _, l := range []label{
+		{"app_name", env.AppName},
+		{"app_version", env.AppVersion},
+		{"os_platform", env.OSPlatform},
+		{"os_release", env.OSRelease},
+		{"os_version", env.OSVersion},
+		{"build_name", env.BuildName},
+		{"build_number", env.BuildNumber},
+	} {
+		if l.value != "" {
+			labels = append(labels, l)
+		}
+	}
+
+	return labels
+}
+
+func writeLabels(b *bytes.Buffer, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", l.name, labelValueReplacer.Replace(l.value))
+	}
+	b.WriteByte('}')
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func seconds(millis int64) float64 {
+	return float64(millis) / 1000
+}
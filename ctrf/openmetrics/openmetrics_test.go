@@ -0,0 +1,98 @@
+package openmetrics_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/openmetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	report := metricsReport()
+
+	var b bytes.Buffer
+	require.NoError(t, openmetrics.Write(&b, report, openmetrics.OpenMetrics))
+
+	assert.Equal(t, `# HELP ctrf_tests The number of tests.
+# TYPE ctrf_tests counter
+ctrf_tests_total{app_name="my-app",build_number="42"} 2
+# HELP ctrf_tests_passed The number of passed tests.
+# TYPE ctrf_tests_passed counter
+ctrf_tests_passed_total{app_name="my-app",build_number="42"} 1
+# HELP ctrf_tests_failed The number of failed tests.
+# TYPE ctrf_tests_failed counter
+ctrf_tests_failed_total{app_name="my-app",build_number="42"} 1
+# HELP ctrf_tests_skipped The number of skipped tests.
+# TYPE ctrf_tests_skipped counter
+ctrf_tests_skipped_total{app_name="my-app",build_number="42"} 0
+# HELP ctrf_tests_pending The number of pending tests.
+# TYPE ctrf_tests_pending counter
+ctrf_tests_pending_total{app_name="my-app",build_number="42"} 0
+# HELP ctrf_tests_other The number of tests with another status.
+# TYPE ctrf_tests_other counter
+ctrf_tests_other_total{app_name="my-app",build_number="42"} 0
+# HELP ctrf_tests_flaky The number of flaky tests.
+# TYPE ctrf_tests_flaky counter
+ctrf_tests_flaky_total{app_name="my-app",build_number="42"} 0
+# HELP ctrf_run_start_timestamp_seconds The time the run started, in seconds since the epoch.
+# TYPE ctrf_run_start_timestamp_seconds gauge
+ctrf_run_start_timestamp_seconds{app_name="my-app",build_number="42"} 1700000000
+# HELP ctrf_run_duration_seconds The duration of the run.
+# TYPE ctrf_run_duration_seconds gauge
+ctrf_run_duration_seconds{app_name="my-app",build_number="42"} 2.5
+# HELP ctrf_test_duration_seconds The duration of a test.
+# TYPE ctrf_test_duration_seconds gauge
+ctrf_test_duration_seconds{suite="pkg/a",name="TestA",status="passed",app_name="my-app",build_number="42"} 1.25
+ctrf_test_duration_seconds{suite="pkg/a",name="TestB/\"quoted\"\\",status="failed",app_name="my-app",build_number="42"} 0.001
+# EOF
+`, b.String())
+
+	b.Reset()
+	require.NoError(t, openmetrics.Write(&b, report, openmetrics.Text))
+	assert.Contains(t, b.String(), "# TYPE ctrf_tests_total counter\nctrf_tests_total{")
+	assert.NotContains(t, b.String(), "# EOF")
+}
+
+func TestPush(t *testing.T) {
+	var (
+		method, path, contentType string
+		body                      []byte
+	)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		if strings.Contains(path, "broken") {
+			http.Error(w, "invalid metrics", http.StatusBadRequest)
+		}
+	}))
+	defer gateway.Close()
+
+	err := openmetrics.Push(context.Background(), gateway.Client(), gateway.URL+"/", "unit tests", map[string]string{"branch": "main"}, metricsReport())
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/unit%20tests/branch/main", path)
+	assert.Equal(t, openmetrics.ContentTypeText, contentType)
+	assert.Contains(t, string(body), "ctrf_tests_failed_total{")
+
+	err = openmetrics.Push(context.Background(), gateway.Client(), gateway.URL, "broken", nil, metricsReport())
+	require.ErrorContains(t, err, "400 Bad Request: invalid metrics")
+}
+
+func metricsReport() *ctrf.Report {
+	report := ctrf.NewReport("gotest", &ctrf.Environment{AppName: "my-app", BuildNumber: "42"})
+	report.Results.Summary = &ctrf.Summary{Tests: 2, Passed: 1, Failed: 1, Start: 1700000000000, Stop: 1700000002500}
+	report.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg/a"}, Name: "TestA", Status: ctrf.TestPassed, Duration: 1250},
+		{Suite: []string{"pkg/a"}, Name: `TestB/"quoted"\`, Status: ctrf.TestFailed, Duration: 1},
+	}
+
+	return report
+}
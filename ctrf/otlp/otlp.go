@@ -0,0 +1,435 @@
+// Package otlp exports a CTRF report as OpenTelemetry traces, encoded as OTLP/JSON.
+//
+// A run is a trace: its root span covers the run, with a child span per package (i.e. per first level of the suite of the tests),
+// which parents the spans of its tests. Subtests are children of their parent test, when the report has it.
+// Retries are events of the span of their test.
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// scopeName is the name of the instrumentation scope of the spans.
+const scopeName = "github.com/ctrf-io/go-ctrf-json-reporter"
+
+// Span kinds and status codes, as defined by OTLP.
+const (
+	spanKindInternal = 1
+
+	statusUnset = 0
+	statusOK    = 1
+	statusError = 2
+)
+
+// TracesData is the OTLP/JSON payload of traces.
+type TracesData struct {
+	ResourceSpans []*ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans are the spans of a resource.
+type ResourceSpans struct {
+	Resource   Resource      `json:"resource"`
+	ScopeSpans []*ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource describes the entity that produced the spans, i.e. the application under test.
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// ScopeSpans are the spans of an instrumentation scope.
+type ScopeSpans struct {
+	Scope Scope   `json:"scope"`
+	Spans []*Span `json:"spans"`
+}
+
+// Scope is an instrumentation scope.
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// Span is an OTLP span. Identifiers are hex-encoded, and timestamps are nanoseconds since the epoch, as strings.
+type Span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Events            []Event    `json:"events,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+// Event is an event of a span.
+type Event struct {
+	TimeUnixNano string     `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []KeyValue `json:"attributes,omitempty"`
+}
+
+// Status is the status of a span.
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyValue is an attribute.
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue is the value of an attribute. Only one of the fields is set.
+type AnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+// Export converts a report into traces.
+//
+// The trace identifier is derived from the identifier of the report when it is a UUID, and span identifiers
+// are derived from the trace identifier and the names of the spans: exporting a report twice yields the same trace.
+func Export(report *ctrf.Report) *TracesData {
+	traceID := traceIDOf(report.ReportId)
+	results := report.Results
+	summary := results.Summary
+	if summary == nil {
+		summary = &ctrf.Summary{}
+	}
+
+	toolName := ""
+	if results.Tool != nil {
+		toolName = results.Tool.Name
+	}
+
+	root := &Span{
+		TraceID:           traceID,
+		SpanID:            spanID(traceID, ""),
+		Name:              "test run",
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: nanos(summary.Start),
+		EndTimeUnixNano:   nanos(summary.Stop),
+		Attributes: []KeyValue{
+			stringAttribute("ctrf.tool.name", toolName),
+			intAttribute("ctrf.summary.tests", int64(summary.Tests)),
+			intAttribute("ctrf.summary.passed", int64(summary.Passed)),
+			intAttribute("ctrf.summary.failed", int64(summary.Failed)),
+			intAttribute("ctrf.summary.skipped", int64(summary.Skipped)),
+			intAttribute("ctrf.summary.flaky", int64(summary.Flaky)),
+		},
+		Status: Status{Code: statusOK},
+	}
+	if summary.Failed > 0 {
+		root.Status = Status{Code: statusError, Message: fmt.Sprintf("%d failed test(s)", summary.Failed)}
+	}
+
+	spans := []*Span{root}
+	packages := make(map[string]*Span)
+	testSpans := make(map[string]*Span, len(results.Tests))
+	for _, test := range results.Tests {
+		span := testSpan(traceID, test)
+		testSpans[spanKey(test.Suite, test.Name)] = span
+		spans = append(spans, span)
+	}
+
+	// subtests complete before their parent test: parents are only known once all the spans are created
+	for i, test := range results.Tests {
+		span := spans[i+1]
+
+		parent := root
+		if len(test.Suite) > 0 {
+			pkg, ok := packages[test.Suite[0]]
+			if !ok {
+				pkg = packageSpan(root, test.Suite[0])
+				packages[test.Suite[0]] = pkg
+				spans = append(spans, pkg)
+			}
+			widen(pkg, span)
+			if test.Status == ctrf.TestFailed {
+				pkg.Status = Status{Code: statusError}
+			}
+			parent = pkg
+		}
+		if slash := strings.LastIndex(test.Name, "/"); slash > 0 {
+			if parentTest, ok := testSpans[spanKey(test.Suite, test.Name[:slash])]; ok {
+				parent = parentTest
+			}
+		}
+
+		span.ParentSpanID = parent.SpanID
+	}
+
+	return &TracesData{ResourceSpans: []*ResourceSpans{{
+		Resource:   Resource{Attributes: resourceAttributes(results.Environment, toolName)},
+		ScopeSpans: []*ScopeSpans{{Scope: Scope{Name: scopeName}, Spans: spans}},
+	}}}
+}
+
+// WriteFile writes the traces as OTLP/JSON to a file.
+func (traces *TracesData) WriteFile(filename string) error {
+	data, err := json.Marshal(traces)
+	if err != nil {
+		return fmt.Errorf("error encoding traces: %w", err)
+	}
+
+	if err := os.WriteFile(filename, data, 0o644); err != nil { //nolint:gosec // the file is meant to be shared
+		return fmt.Errorf("error writing traces: %w", err)
+	}
+
+	return nil
+}
+
+// Send posts the traces as OTLP/JSON to an OTLP/HTTP endpoint, with additional headers (e.g. for authentication).
+//
+// An endpoint without a path, e.g. "http://localhost:4318", is sent to the default "/v1/traces" path.
+func (traces *TracesData) Send(ctx context.Context, client *http.Client, endpoint string, headers map[string]string) error {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid OTLP endpoint: %w", err)
+	}
+	if endpointURL.Path == "" || endpointURL.Path == "/" {
+		endpointURL.Path = "/v1/traces"
+	}
+
+	data, err := json.Marshal(traces)
+	if err != nil {
+		return fmt.Errorf("error encoding traces: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error sending traces: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending traces: %w", err)
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+
+		return fmt.Errorf("error sending traces: %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// ParseHeaders parses headers in the format of OTEL_EXPORTER_OTLP_HEADERS, e.g. "api-key=secret,tenant=team-a".
+func ParseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		key, headerValue, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q: expected key=value", pair)
+		}
+
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(headerValue)); err == nil {
+			headerValue = unescaped
+		}
+		headers[strings.TrimSpace(key)] = headerValue
+	}
+
+	return headers, nil
+}
+
+func packageSpan(root *Span, pkg string) *Span {
+	return &Span{
+		TraceID:      root.TraceID,
+		SpanID:       spanID(root.TraceID, pkg),
+		ParentSpanID: root.SpanID,
+		Name:         pkg,
+		Kind:         spanKindInternal,
+		Attributes:   []KeyValue{stringAttribute("test.suite.name", pkg)},
+		Status:       Status{Code: statusOK},
+	}
+}
+
+// testSpan builds the span of a test. Its parent is set by Export.
+func testSpan(traceID string, test *ctrf.TestResult) *Span {
+	start, stop := test.Start, test.Stop
+	switch {
+	case start == 0 && stop == 0:
+		start, stop = 0, test.Duration
+	case start == 0:
+		start = stop - test.Duration
+	case stop == 0:
+		stop = start + test.Duration
+	}
+
+	span := &Span{
+		TraceID:           traceID,
+		SpanID:            spanID(traceID, spanKey(test.Suite, test.Name)),
+		Name:              test.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: nanos(start),
+		EndTimeUnixNano:   nanos(stop),
+		Attributes: []KeyValue{
+			stringAttribute("test.case.name", test.Name),
+			stringAttribute("test.case.result.status", string(test.Status)),
+		},
+	}
+	if len(test.Suite) > 0 {
+		span.Attributes = append(span.Attributes, stringAttribute("test.suite.name", strings.Join(test.Suite, "/")))
+	}
+	if test.Filepath != "" {
+		span.Attributes = append(span.Attributes, stringAttribute("code.filepath", test.Filepath))
+	}
+	if len(test.Tags) > 0 {
+		span.Attributes = append(span.Attributes, stringAttribute("ctrf.tags", strings.Join(test.Tags, ",")))
+	}
+	if test.Flaky {
+		span.Attributes = append(span.Attributes, boolAttribute("ctrf.flaky", true))
+	}
+	if test.Retries > 0 {
+		span.Attributes = append(span.Attributes, intAttribute("ctrf.retries", int64(test.Retries)))
+	}
+
+	switch test.Status {
+	case ctrf.TestPassed:
+		span.Status = Status{Code: statusOK}
+	case ctrf.TestFailed:
+		span.Status = Status{Code: statusError, Message: test.Message}
+	default:
+		span.Status = Status{Code: statusUnset}
+	}
+
+	for _, attempt := range test.RetryAttempts {
+		at := attempt.Stop
+		if at == 0 {
+			at = attempt.Start
+		}
+		event := Event{
+			TimeUnixNano: nanos(at),
+			Name:         "retry",
+			Attributes: []KeyValue{
+				intAttribute("ctrf.attempt", int64(attempt.Attempt)),
+				stringAttribute("test.case.result.status", string(attempt.Status)),
+				intAttribute("ctrf.duration", attempt.Duration),
+			},
+		}
+		if attempt.Message != "" {
+			event.Attributes = append(event.Attributes, stringAttribute("ctrf.message", attempt.Message))
+		}
+		span.Events = append(span.Events, event)
+	}
+
+	return span
+}
+
+// widen extends the time range of a package span to cover the span of one of its tests.
+func widen(pkg, test *Span) {
+	if pkg.StartTimeUnixNano == "" || unnanos(test.StartTimeUnixNano) < unnanos(pkg.StartTimeUnixNano) {
+		pkg.StartTimeUnixNano = test.StartTimeUnixNano
+	}
+	if pkg.EndTimeUnixNano == "" || unnanos(test.EndTimeUnixNano) > unnanos(pkg.EndTimeUnixNano) {
+		pkg.EndTimeUnixNano = test.EndTimeUnixNano
+	}
+}
+
+func resourceAttributes(env *ctrf.Environment, toolName string) []KeyValue {
+	serviceName := toolName
+	if env != nil && env.AppName != "" {
+		serviceName = env.AppName
+	}
+
+	attributes := []KeyValue{stringAttribute("service.name", serviceName)}
+	if env == nil {
+		return attributes
+	}
+
+	for _, attribute := range []struct{ key, value string }{
+		{"service.version", env.AppVersion},
+		{"os.type", env.OSPlatform},
+		{"os.version", env.OSVersion},
+		{"ctrf.os.release", env.OSRelease},
+		{"ctrf.build.name", env.BuildName},
+		{"ctrf.build.number", env.BuildNumber},
+	} {
+		if attribute.value != "" {
+			attributes = append(attributes, stringAttribute(attribute.key, attribute.value))
+		}
+	}
+
+	return attributes
+}
+
+// traceIDOf derives a trace identifier from a UUID, or generates a random one.
+func traceIDOf(reportID string) string {
+	if id := strings.ReplaceAll(reportID, "-", ""); len(id) == 32 {
+		if _, err := hex.DecodeString(id); err == nil {
+			return strings.ToLower(id)
+		}
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// spanKey identifies the span of a test in a trace.
+func spanKey(suite []string, name string) string {
+	return strings.Join(suite, "/") + "." + name
+}
+
+// spanID derives the identifier of a span from the trace identifier and the name of the span.
+func spanID(traceID, name string) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(traceID + "\x00" + name))
+
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+func nanos(millis int64) string {
+	return strconv.FormatInt(millis*int64(time.Millisecond), 10)
+}
+
+func unnanos(value string) int64 {
+	n, _ := strconv.ParseInt(value, 10, 64)
+
+	return n
+}
+
+func stringAttribute(key, value string) KeyValue {
+	return KeyValue{Key: key, Value: AnyValue{StringValue: &value}}
+}
+
+func boolAttribute(key string, value bool) KeyValue {
+	return KeyValue{Key: key, Value: AnyValue{BoolValue: &value}}
+}
+
+func intAttribute(key string, value int64) KeyValue {
+	formatted := strconv.FormatInt(value, 10)
+
+	return KeyValue{Key: key, Value: AnyValue{IntValue: &formatted}}
+}
@@ -0,0 +1,154 @@
+package otlp_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/otlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	report := ctrf.NewReport("gotest", &ctrf.Environment{AppName: "my-app", AppVersion: "1.2.3", BuildNumber: "42"})
+	report.ReportId = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	report.Results.Summary = &ctrf.Summary{Tests: 3, Passed: 2, Failed: 1, Start: 1000, Stop: 5000}
+	report.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg/a"}, Name: "TestA/sub", Status: ctrf.TestFailed, Message: "boom", Start: 1100, Stop: 1200, Duration: 100},
+		{Suite: []string{"pkg/a"}, Name: "TestA", Status: ctrf.TestFailed, Start: 1000, Stop: 1300, Duration: 300},
+		{
+			Suite: []string{"pkg/b"}, Name: "TestB", Status: ctrf.TestPassed, Flaky: true, Retries: 2, Start: 2000, Stop: 4000, Duration: 2000,
+			RetryAttempts: []ctrf.RetryAttempt{
+				{Attempt: 1, Status: ctrf.TestFailed, Message: "flaky", Duration: 1000, Start: 2000, Stop: 3000},
+				{Attempt: 2, Status: ctrf.TestPassed, Duration: 1000, Start: 3000, Stop: 4000},
+			},
+		},
+	}
+
+	traces := otlp.Export(report)
+
+	require.Len(t, traces.ResourceSpans, 1)
+	resource := traces.ResourceSpans[0]
+	assert.Equal(t, map[string]string{
+		"service.name":      "my-app",
+		"service.version":   "1.2.3",
+		"ctrf.build.number": "42",
+	}, attributes(resource.Resource.Attributes))
+
+	spans := make(map[string]*otlp.Span)
+	for _, span := range resource.ScopeSpans[0].Spans {
+		assert.Equal(t, "0f8fad5bd9cb469fa16570867728950e", span.TraceID)
+		assert.Len(t, span.SpanID, 16)
+		spans[span.Name] = span
+	}
+	require.Len(t, spans, 6)
+
+	t.Run("should nest packages, tests and subtests", func(t *testing.T) {
+		root := spans["test run"]
+		assert.Empty(t, root.ParentSpanID)
+		assert.Equal(t, "1000000000", root.StartTimeUnixNano)
+		assert.Equal(t, "5000000000", root.EndTimeUnixNano)
+		assert.Equal(t, otlp.Status{Code: 2, Message: "1 failed test(s)"}, root.Status)
+
+		assert.Equal(t, root.SpanID, spans["pkg/a"].ParentSpanID)
+		assert.Equal(t, spans["pkg/a"].SpanID, spans["TestA"].ParentSpanID)
+		assert.Equal(t, spans["TestA"].SpanID, spans["TestA/sub"].ParentSpanID)
+		assert.Equal(t, spans["pkg/b"].SpanID, spans["TestB"].ParentSpanID)
+
+		assert.Equal(t, "1000000000", spans["pkg/a"].StartTimeUnixNano)
+		assert.Equal(t, "1300000000", spans["pkg/a"].EndTimeUnixNano)
+		assert.Equal(t, 2, spans["pkg/a"].Status.Code)
+		assert.Equal(t, 1, spans["pkg/b"].Status.Code)
+		assert.Equal(t, otlp.Status{Code: 2, Message: "boom"}, spans["TestA/sub"].Status)
+	})
+
+	t.Run("should record retries as events", func(t *testing.T) {
+		testB := spans["TestB"]
+		assert.Equal(t, map[string]string{
+			"test.case.name":          "TestB",
+			"test.case.result.status": "passed",
+			"test.suite.name":         "pkg/b",
+			"ctrf.flaky":              "true",
+			"ctrf.retries":            "2",
+		}, attributes(testB.Attributes))
+
+		require.Len(t, testB.Events, 2)
+		assert.Equal(t, "retry", testB.Events[0].Name)
+		assert.Equal(t, "3000000000", testB.Events[0].TimeUnixNano)
+		assert.Equal(t, "flaky", attributes(testB.Events[0].Attributes)["ctrf.message"])
+	})
+
+	t.Run("should be stable", func(t *testing.T) {
+		assert.Equal(t, traces, otlp.Export(report))
+	})
+
+	t.Run("should write OTLP/JSON", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "traces.json")
+		require.NoError(t, traces.WriteFile(filename))
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `{"key":"ctrf.retries","value":{"intValue":"2"}}`)
+	})
+
+	t.Run("should send to a collector", func(t *testing.T) {
+		var received otlp.TracesData
+		collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Api-Key") != "secret" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, &received); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer collector.Close()
+
+		headers, err := otlp.ParseHeaders("api-key=secret")
+		require.NoError(t, err)
+
+		require.NoError(t, traces.Send(context.Background(), collector.Client(), collector.URL, headers))
+		assert.Equal(t, traces, &received)
+
+		err = traces.Send(context.Background(), collector.Client(), collector.URL, nil)
+		require.ErrorContains(t, err, "400 Bad Request: unexpected request")
+	})
+
+	t.Run("should parse headers", func(t *testing.T) {
+		headers, err := otlp.ParseHeaders("api-key=a%20b, tenant = team-a,")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"api-key": "a b", "tenant": "team-a"}, headers)
+
+		_, err = otlp.ParseHeaders("no-value")
+		require.Error(t, err)
+	})
+}
+
+// attributes flattens attributes, for comparison.
+func attributes(keyValues []otlp.KeyValue) map[string]string {
+	flattened := make(map[string]string, len(keyValues))
+	for _, keyValue := range keyValues {
+		switch {
+		case keyValue.Value.StringValue != nil:
+			flattened[keyValue.Key] = *keyValue.Value.StringValue
+		case keyValue.Value.IntValue != nil:
+			flattened[keyValue.Key] = *keyValue.Value.IntValue
+		case keyValue.Value.BoolValue != nil && *keyValue.Value.BoolValue:
+			flattened[keyValue.Key] = "true"
+		}
+	}
+
+	return flattened
+}
@@ -0,0 +1,126 @@
+// Package shard splits tests into balanced shards, from the durations recorded by a previous report.
+package shard
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+)
+
+// Shard is a set of items (packages or tests) to run together.
+type Shard struct {
+	Items    []string
+	Duration int64 // the expected duration of the shard, in milliseconds, from the known items only
+	Unknown  int   // the number of items without a recorded duration
+}
+
+// PackageDurations returns the duration of each package of a report, as the sum of the durations of its top-level tests.
+func PackageDurations(report *ctrf.Report) map[string]int64 {
+	durations := make(map[string]int64)
+	for _, test := range report.Results.Tests {
+		if len(test.Suite) == 0 || !isTopLevelTest(test) {
+			continue
+		}
+		durations[test.Suite[0]] += test.Duration
+	}
+
+	return durations
+}
+
+// TestDurations returns the duration of each top-level test of a report.
+//
+// Tests are identified by name only, as "go test -run" selects them by name: the durations of tests
+// with the same name in several packages are summed.
+func TestDurations(report *ctrf.Report) map[string]int64 {
+	durations := make(map[string]int64)
+	for _, test := range report.Results.Tests {
+		if !isTopLevelTest(test) {
+			continue
+		}
+		durations[test.Name] += test.Duration
+	}
+
+	return durations
+}
+
+// isTopLevelTest tells if a result is the one of a top-level test, and not of a subtest, nor of the failed test
+// that stands for a package that did not build, which can't be selected with "go test -run".
+func isTopLevelTest(test *ctrf.TestResult) bool {
+	return !strings.Contains(test.Name, "/") && test.Name != reporter.BuildFailureTestName
+}
+
+// Split assigns items to n shards.
+//
+// Items with a known duration are assigned, from the longest to the shortest, to the shard with the shortest
+// expected duration so far (greedy bin-packing). Items without a known duration are then distributed evenly,
+// to the shard with the fewest unknown items, then with the shortest expected duration.
+// A nil list of items splits all the items with a known duration.
+//
+// The result only depends on the set of items and on their durations, so that every shard computes the same split.
+func Split(items []string, durations map[string]int64, n int) []*Shard {
+	if items == nil {
+		items = make([]string, 0, len(durations))
+		for item := range durations {
+			items = append(items, item)
+		}
+	}
+
+	var known, unknown []string
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		if seen[item] {
+			continue
+		}
+		seen[item] = true
+
+		if _, ok := durations[item]; ok {
+			known = append(known, item)
+		} else {
+			unknown = append(unknown, item)
+		}
+	}
+
+	sort.Slice(known, func(i, j int) bool {
+		if durations[known[i]] != durations[known[j]] {
+			return durations[known[i]] > durations[known[j]]
+		}
+
+		return known[i] < known[j]
+	})
+	sort.Strings(unknown)
+
+	shards := make([]*Shard, n)
+	for i := range shards {
+		shards[i] = &Shard{Items: []string{}}
+	}
+
+	for _, item := range known {
+		shortest := shards[0]
+		for _, shard := range shards[1:] {
+			if shard.Duration < shortest.Duration {
+				shortest = shard
+			}
+		}
+		shortest.Items = append(shortest.Items, item)
+		shortest.Duration += durations[item]
+	}
+
+	for _, item := range unknown {
+		fewest := shards[0]
+		for _, shard := range shards[1:] {
+			if shard.Unknown < fewest.Unknown || (shard.Unknown == fewest.Unknown && shard.Duration < fewest.Duration) {
+				fewest = shard
+			}
+		}
+		fewest.Items = append(fewest.Items, item)
+		fewest.Unknown++
+	}
+
+	for _, shard := range shards {
+		sort.Strings(shard.Items)
+	}
+
+	return shards
+}
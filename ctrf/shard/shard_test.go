@@ -0,0 +1,57 @@
+package shard_test
+
+import (
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/shard"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	report := ctrf.NewReport("gotest", nil)
+	report.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg/a"}, Name: "TestA1", Duration: 700},
+		{Suite: []string{"pkg/a"}, Name: "TestA1/sub", Duration: 700},
+		{Suite: []string{"pkg/a"}, Name: "TestA2", Duration: 100},
+		{Suite: []string{"pkg/b"}, Name: "TestB", Duration: 500},
+		{Suite: []string{"pkg/c"}, Name: "TestC", Duration: 300},
+		{Suite: []string{"pkg/d"}, Name: "TestD", Duration: 200},
+		{Suite: []string{"pkg/d"}, Name: "TestA2", Duration: 50},
+		{Suite: []string{"pkg/e"}, Name: reporter.BuildFailureTestName, Status: ctrf.TestFailed}, // not a test to run
+	}
+
+	t.Run("should compute durations", func(t *testing.T) {
+		assert.Equal(t, map[string]int64{"pkg/a": 800, "pkg/b": 500, "pkg/c": 300, "pkg/d": 250}, shard.PackageDurations(report))
+		assert.Equal(t, map[string]int64{"TestA1": 700, "TestA2": 150, "TestB": 500, "TestC": 300, "TestD": 200}, shard.TestDurations(report))
+	})
+
+	t.Run("should balance known durations", func(t *testing.T) {
+		shards := shard.Split(nil, shard.PackageDurations(report), 2)
+
+		assert.Equal(t, []*shard.Shard{
+			{Items: []string{"pkg/a", "pkg/d"}, Duration: 1050},
+			{Items: []string{"pkg/b", "pkg/c"}, Duration: 800},
+		}, shards)
+	})
+
+	t.Run("should distribute unknown items evenly", func(t *testing.T) {
+		items := []string{"pkg/new2", "pkg/b", "pkg/new1", "pkg/a", "pkg/new3", "pkg/a"}
+
+		shards := shard.Split(items, shard.PackageDurations(report), 2)
+
+		assert.Equal(t, []*shard.Shard{
+			{Items: []string{"pkg/a", "pkg/new2"}, Duration: 800, Unknown: 1},
+			{Items: []string{"pkg/b", "pkg/new1", "pkg/new3"}, Duration: 500, Unknown: 2},
+		}, shards)
+	})
+
+	t.Run("should leave extra shards empty", func(t *testing.T) {
+		shards := shard.Split([]string{"TestB"}, shard.TestDurations(report), 3)
+
+		assert.Equal(t, []string{"TestB"}, shards[0].Items)
+		assert.Empty(t, shards[1].Items)
+		assert.Empty(t, shards[2].Items)
+	})
+}
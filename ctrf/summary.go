@@ -0,0 +1,140 @@
+package ctrf
+
+import "strings"
+
+// RecomputeSummary derives the counts and the timestamps of the summary from the tests of the report,
+// e.g. after tests were added, removed or changed, following the rule of Summary for the flaky tests.
+//
+// The suites are the distinct suites of the tests, at every level of their hierarchy. The summary starts
+// with the earliest test and stops with the latest one; its timestamps are left as is when no test is timed.
+// The extra fields of the summary are kept.
+func (report *Report) RecomputeSummary() {
+	if report.Results == nil {
+		return
+	}
+
+	previous := report.Results.Summary
+	summary := &Summary{}
+	if previous != nil {
+		summary.Start, summary.Stop, summary.Extra = previous.Start, previous.Stop, previous.Extra
+	}
+
+	suites := make(map[string]bool)
+	timed := false
+	for _, test := range report.Results.Tests {
+		summary.count(test, 1)
+		summary.Suites += addSuites(suites, test.Suite)
+
+		if test.Start == 0 && test.Stop == 0 {
+			continue
+		}
+		if !timed {
+			summary.Start, summary.Stop = test.Start, test.Stop
+			timed = true
+		}
+		summary.extend(test.Start, test.Stop)
+	}
+	summary.Duration = summary.Stop - summary.Start
+
+	report.Results.Summary = summary
+}
+
+// statusSum is the sum of the counts of the statuses.
+func (summary *Summary) statusSum() int {
+	return summary.Passed + summary.Failed + summary.Pending + summary.Skipped + summary.Other
+}
+
+// statusCount returns the count of the summary for a status.
+func (summary *Summary) statusCount(status TestStatus) *int {
+	switch status {
+	case TestPassed:
+		return &summary.Passed
+	case TestFailed:
+		return &summary.Failed
+	case TestSkipped:
+		return &summary.Skipped
+	case TestPending:
+		return &summary.Pending
+	default:
+		return &summary.Other
+	}
+}
+
+// countFlakyUnderStatus converts the counts of a summary of version 0, where Tests is the sum of Passed, Failed,
+// Pending, Skipped, Other and Flaky, to the ones of Summary: they are recomputed from the tests, or else the flaky
+// tests are counted as passed, as they eventually passed.
+// A summary which counts do not add up as in version 0 is left as is.
+func (summary *Summary) countFlakyUnderStatus(tests []*TestResult) {
+	if summary.Flaky == 0 || summary.Tests != summary.statusSum()+summary.Flaky {
+		return
+	}
+
+	if len(tests) == 0 {
+		summary.Passed += summary.Flaky
+
+		return
+	}
+
+	counts := &Summary{}
+	for _, test := range tests {
+		counts.count(test, 1)
+	}
+	summary.Tests, summary.Passed, summary.Failed = counts.Tests, counts.Passed, counts.Failed
+	summary.Pending, summary.Skipped, summary.Other, summary.Flaky = counts.Pending, counts.Skipped, counts.Other, counts.Flaky
+}
+
+// countFlakyApart converts the counts of a summary to the ones of version 0, taking the flaky tests out of the
+// counts of their status, or else out of the passed tests. A summary which counts do not add up as described
+// by Summary is left as is.
+func (summary *Summary) countFlakyApart(tests []*TestResult) {
+	if summary.Flaky == 0 || summary.Tests != summary.statusSum() {
+		return
+	}
+
+	if len(tests) == 0 {
+		if summary.Passed >= summary.Flaky {
+			summary.Passed -= summary.Flaky
+		}
+
+		return
+	}
+
+	for _, test := range tests {
+		if test.Flaky {
+			*summary.statusCount(test.Status)--
+		}
+	}
+}
+
+// count adds a test to the counts of the summary, or removes it with a negative delta.
+func (summary *Summary) count(test *TestResult, delta int) {
+	summary.Tests += delta
+	if test.Flaky {
+		summary.Flaky += delta
+	}
+	*summary.statusCount(test.Status) += delta
+}
+
+// extend extends the period of the summary to a period, which start or stop may be unknown (zero).
+func (summary *Summary) extend(start, stop int64) {
+	if start != 0 && (summary.Start == 0 || start < summary.Start) {
+		summary.Start = start
+	}
+	if stop > summary.Stop {
+		summary.Stop = stop
+	}
+}
+
+// addSuites records a suite and the suites above it, and returns how many of them were not recorded yet.
+func addSuites(suites map[string]bool, suite []string) int {
+	added := 0
+	for i := range suite {
+		key := strings.Join(suite[:i+1], "\x00")
+		if !suites[key] {
+			suites[key] = true
+			added++
+		}
+	}
+
+	return added
+}
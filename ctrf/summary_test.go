@@ -0,0 +1,53 @@
+package ctrf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecomputeSummary(t *testing.T) {
+	t.Run("should derive the summary from the tests", func(t *testing.T) {
+		report := NewReport("gotest", nil)
+		report.Results.Summary = &Summary{Tests: 1, Passed: 1, Flaky: 1, Start: 500, Stop: 600, Extra: map[string]any{"quarantined": 1}}
+		report.Results.Tests = []*TestResult{
+			{Name: "TestA", Suite: []string{"pkg/a"}, Status: TestPassed, Flaky: true, Start: 1000, Stop: 1200},
+			{Name: "TestB", Suite: []string{"pkg/a"}, Status: TestFailed, Start: 1100, Stop: 1500},
+			{Name: "TestC", Suite: []string{"pkg/b", "sub"}, Status: TestSkipped},
+			{Name: "TestD", Suite: []string{"pkg/b"}, Status: TestPending, Stop: 900},
+			{Name: "TestE", Status: "unknown"},
+		}
+
+		report.RecomputeSummary()
+		require.Empty(t, report.Validate())
+		assert.Equal(t, &Summary{
+			Tests: 5, Passed: 1, Failed: 1, Skipped: 1, Pending: 1, Other: 1, Flaky: 1, Suites: 3,
+			Start: 1000, Stop: 1500, Duration: 500,
+			Extra: map[string]any{"quarantined": 1},
+		}, report.Results.Summary)
+	})
+
+	t.Run("should keep the timestamps when no test is timed", func(t *testing.T) {
+		report := NewReport("gotest", nil)
+		report.Results.Summary = &Summary{Tests: 3, Start: 500, Stop: 600}
+		report.Results.Tests = []*TestResult{{Name: "TestA", Status: TestPassed}}
+
+		report.RecomputeSummary()
+		assert.Equal(t, &Summary{Tests: 1, Passed: 1, Start: 500, Stop: 600, Duration: 100}, report.Results.Summary)
+	})
+
+	t.Run("should fix a summary that fails validation", func(t *testing.T) {
+		report := NewReport("gotest", nil)
+		report.Results.Summary = &Summary{Tests: 1, Flaky: 1}
+		report.Results.Tests = []*TestResult{{Name: "TestA", Status: TestPassed, Flaky: true}}
+
+		var out strings.Builder
+		err := report.Write(&out, false)
+		require.EqualError(t, err, "report is invalid: invalid summary counts: tests (1) must be the sum of passed (0), failed (0), pending (0), skipped (0), and other (0), i.e. 0")
+
+		report.RecomputeSummary()
+		require.NoError(t, report.Write(&out, false))
+	})
+}
@@ -0,0 +1,208 @@
+// Package tap writes CTRF reports in the Test Anything Protocol (TAP), version 14.
+//
+// TAP 13 consumers can read the output too: they ignore the indented subtests.
+package tap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"gopkg.in/yaml.v3"
+)
+
+// indent is the indentation of a level of subtests.
+const indent = "    "
+
+// point is a test point, with the test points of its subtests.
+type point struct {
+	test      *ctrf.TestResult
+	subtests  []*point
+	suitePath string
+}
+
+// diagnostic is the YAML diagnostic block of a failed test point.
+type diagnostic struct {
+	Message    string `yaml:"message,omitempty"`
+	Severity   string `yaml:"severity"`
+	Stack      string `yaml:"stack,omitempty"`
+	File       string `yaml:"file,omitempty"`
+	DurationMS int64  `yaml:"duration_ms"`
+}
+
+// Write writes a report in TAP.
+//
+// Each test result is a test point, and the subtests of a test, e.g. "TestA/sub" for "TestA" in the same suite,
+// are nested in it as TAP subtests. Skipped tests are "ok" with a SKIP directive, pending tests are "not ok"
+// with a TODO directive, and the message and trace of failures are written in YAML diagnostic blocks.
+// The plan is the number of tests of the summary, less the nested subtests.
+func Write(w io.Writer, report *ctrf.Report) error {
+	points, nested := tree(report.Results.Tests)
+
+	planned := len(report.Results.Tests)
+	if report.Results.Summary != nil {
+		planned = report.Results.Summary.Tests
+	}
+
+	var b bytes.Buffer
+	b.WriteString("TAP version 14\n")
+	fmt.Fprintf(&b, "1..%d\n", planned-nested)
+	for i, p := range points {
+		if err := writePoint(&b, p, i+1, ""); err != nil {
+			return fmt.Errorf("error writing TAP: %w", err)
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+
+	return err
+}
+
+// WriteFile writes a report to a file, in TAP.
+func WriteFile(filename string, report *ctrf.Report) error {
+	var b bytes.Buffer
+	if err := Write(&b, report); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filename, b.Bytes(), 0o644); err != nil { //nolint:gosec // the file is meant to be shared
+		return fmt.Errorf("error writing TAP: %w", err)
+	}
+
+	return nil
+}
+
+// tree nests the subtests in their parent test, and returns the top-level test points,
+// along with the number of nested subtests.
+//
+// A subtest whose parent is not in the report stays at the top level.
+func tree(tests []*ctrf.TestResult) ([]*point, int) {
+	byKey := make(map[string]*point, len(tests))
+	points := make([]*point, 0, len(tests))
+	for _, test := range tests {
+		p := &point{test: test, suitePath: strings.Join(test.Suite, " > ")}
+		byKey[key(test.Suite, test.Name)] = p
+		points = append(points, p)
+	}
+
+	var top []*point
+	nested := 0
+	for _, p := range points {
+		parent := parentOf(byKey, p.test)
+		if parent == nil {
+			top = append(top, p)
+
+			continue
+		}
+		parent.subtests = append(parent.subtests, p)
+		nested++
+	}
+
+	return top, nested
+}
+
+// parentOf returns the test point of the closest parent of a subtest, if any.
+func parentOf(byKey map[string]*point, test *ctrf.TestResult) *point {
+	name := test.Name
+	for {
+		slash := strings.LastIndex(name, "/")
+		if slash < 0 {
+			return nil
+		}
+		name = name[:slash]
+		if parent, ok := byKey[key(test.Suite, name)]; ok {
+			return parent
+		}
+	}
+}
+
+func key(suite []string, name string) string {
+	return strings.Join(suite, "\x00") + "\x00" + name
+}
+
+func writePoint(b *bytes.Buffer, p *point, number int, prefix string) error {
+	test := p.test
+	description := test.Name
+	if prefix == "" && p.suitePath != "" {
+		description = p.suitePath + " > " + test.Name
+	}
+
+	if len(p.subtests) > 0 {
+		fmt.Fprintf(b, "%s# Subtest: %s\n", prefix, escape(description))
+		for i, subtest := range p.subtests {
+			if err := writePoint(b, subtest, i+1, prefix+indent); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "%s%s1..%d\n", prefix, indent, len(p.subtests))
+	}
+
+	result := "ok"
+	directive := ""
+	switch test.Status {
+	case ctrf.TestPassed:
+	case ctrf.TestSkipped:
+		directive = " # SKIP"
+		if reason := skipReason(test.Message); reason != "" {
+			directive += " " + reason
+		}
+	case ctrf.TestPending:
+		result = "not ok"
+		directive = " # TODO pending"
+	default:
+		result = "not ok"
+	}
+	fmt.Fprintf(b, "%s%s %d - %s%s\n", prefix, result, number, escape(description), directive)
+
+	if result == "ok" || directive != "" {
+		return nil
+	}
+
+	return writeDiagnostic(b, test, prefix+"  ")
+}
+
+func writeDiagnostic(b *bytes.Buffer, test *ctrf.TestResult, prefix string) error {
+	data, err := yaml.Marshal(diagnostic{
+		Message:    strings.TrimSpace(test.Message),
+		Severity:   "fail",
+		Stack:      strings.TrimSpace(test.Trace),
+		File:       test.Filepath,
+		DurationMS: test.Duration,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(b, "%s---\n", prefix)
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		fmt.Fprintf(b, "%s%s\n", prefix, line)
+	}
+	fmt.Fprintf(b, "%s...\n", prefix)
+
+	return nil
+}
+
+// skipReason extracts the reason of a skip from the message of a test,
+// leaving out the "=== RUN" and "--- SKIP" lines of go test.
+func skipReason(message string) string {
+	var reasons []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "=== ") || strings.HasPrefix(line, "--- ") {
+			continue
+		}
+		reasons = append(reasons, line)
+	}
+
+	return escape(strings.Join(reasons, "; "))
+}
+
+// descriptionReplacer escapes the characters that would end a description, or start a directive.
+var descriptionReplacer = strings.NewReplacer(`\`, `\\`, "#", `\#`, "\n", " ", "\r", "")
+
+func escape(description string) string {
+	return descriptionReplacer.Replace(description)
+}
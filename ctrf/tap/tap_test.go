@@ -0,0 +1,62 @@
+package tap_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/tap"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	report := ctrf.NewReport("gotest", nil)
+	report.Results.Summary = &ctrf.Summary{Tests: 7, Passed: 3, Failed: 2, Skipped: 1, Pending: 1}
+	report.Results.Tests = []*ctrf.TestResult{
+		{Suite: []string{"pkg/a"}, Name: "TestA/ok", Status: ctrf.TestPassed},
+		{Suite: []string{"pkg/a"}, Name: "TestA/fail", Status: ctrf.TestFailed, Duration: 12, Message: "boom\n", Trace: "a_test.go:12"},
+		{Suite: []string{"pkg/a"}, Name: "TestA", Status: ctrf.TestFailed, Duration: 15},
+		{Suite: []string{"pkg/a"}, Name: "TestSkip", Status: ctrf.TestSkipped, Message: "=== RUN   TestSkip\n    a_test.go:20: needs #42\n--- SKIP: TestSkip (0.00s)\n"},
+		{Suite: []string{"pkg/b"}, Name: "TestB", Status: ctrf.TestPassed},
+		{Suite: []string{"pkg/b"}, Name: "TestOrphan/sub", Status: ctrf.TestPassed},
+		{Name: "TestLater", Status: ctrf.TestPending},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, tap.Write(&b, report))
+
+	assert.Equal(t, `TAP version 14
+1..5
+# Subtest: pkg/a > TestA
+    ok 1 - TestA/ok
+    not ok 2 - TestA/fail
+      ---
+      message: boom
+      severity: fail
+      stack: a_test.go:12
+      duration_ms: 12
+      ...
+    1..2
+not ok 1 - pkg/a > TestA
+  ---
+  severity: fail
+  duration_ms: 15
+  ...
+ok 2 - pkg/a > TestSkip # SKIP a_test.go:20: needs \#42
+ok 3 - pkg/b > TestB
+ok 4 - pkg/b > TestOrphan/sub
+not ok 5 - TestLater # TODO pending
+`, b.String())
+
+	t.Run("should write a file", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "report.tap")
+		require.NoError(t, tap.WriteFile(filename, report))
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, b.String(), string(data))
+	})
+}
@@ -0,0 +1,234 @@
+// Package trend analyzes the duration of tests across runs, to detect tests that got slower.
+package trend
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+)
+
+// Options tune the analysis.
+type Options struct {
+	// Factor is how many times slower than its baseline (median) duration a test must be to be flagged as a regression.
+	Factor float64
+
+	// MinDelta is the minimum increase of duration, in milliseconds, for a test to be flagged as a regression.
+	// It keeps very fast tests from being flagged because of noise.
+	MinDelta int64
+
+	// Top is the number of slowest tests and packages to report.
+	Top int
+}
+
+// DefaultOptions are sensible options for the analysis.
+var DefaultOptions = Options{Factor: 2, MinDelta: 100, Top: 10}
+
+// Baseline holds the past durations of the tests.
+type Baseline struct {
+	Runs      int
+	durations map[string][]int64
+}
+
+// TestTrend compares the duration of a test with its baseline.
+type TestTrend struct {
+	Suite     []string `json:"suite,omitempty"`
+	Name      string   `json:"name"`
+	Duration  int64    `json:"duration"`            // the duration in the current run, in milliseconds
+	Samples   int      `json:"samples"`             // the number of past durations of the baseline
+	P50       int64    `json:"p50,omitempty"`       // the median past duration
+	P95       int64    `json:"p95,omitempty"`       // the 95th percentile of the past durations
+	Ratio     float64  `json:"ratio,omitempty"`     // the current duration over the median past duration
+	Regressed bool     `json:"regressed,omitempty"` // the test got slower than its baseline by the factor of the analysis
+}
+
+// PackageDuration is the total duration of the top-level tests of a package (i.e. of the first level of their suite).
+type PackageDuration struct {
+	Package  string `json:"package"`
+	Duration int64  `json:"duration"`
+}
+
+// Analysis is the result of the duration trend analysis of a run.
+type Analysis struct {
+	Factor          float64            `json:"factor"`
+	BaselineRuns    int                `json:"baselineRuns"`
+	Regressions     []*TestTrend       `json:"regressions"`
+	SlowestTests    []*TestTrend       `json:"slowestTests"`
+	SlowestPackages []*PackageDuration `json:"slowestPackages"`
+}
+
+// NewBaseline builds an empty baseline.
+func NewBaseline() *Baseline {
+	return &Baseline{durations: make(map[string][]int64)}
+}
+
+// AddReport adds the durations of the tests of a past report to the baseline.
+func (baseline *Baseline) AddReport(report *ctrf.Report) {
+	baseline.AddRun(history.NewRun(report))
+}
+
+// AddRun adds the durations of the tests of a past run to the baseline.
+//
+// Only passed tests are taken into account, as failed or skipped tests usually don't run to completion.
+func (baseline *Baseline) AddRun(run history.Run) {
+	baseline.Runs++
+
+	for _, test := range run.Tests {
+		if test.Status != ctrf.TestPassed {
+			continue
+		}
+
+		key := history.Key(test.Suite, test.Name)
+		baseline.durations[key] = append(baseline.durations[key], test.Duration)
+	}
+}
+
+// Analyze compares the durations of the tests of the current report with the baseline.
+func Analyze(current *ctrf.Report, baseline *Baseline, options Options) *Analysis {
+	analysis := &Analysis{
+		Factor:          options.Factor,
+		BaselineRuns:    baseline.Runs,
+		Regressions:     []*TestTrend{},
+		SlowestPackages: []*PackageDuration{},
+	}
+
+	trends := make([]*TestTrend, 0, len(current.Results.Tests))
+	packages := make(map[string]*PackageDuration)
+	for _, test := range current.Results.Tests {
+		trend := &TestTrend{Suite: test.Suite, Name: test.Name, Duration: test.Duration}
+		trends = append(trends, trend)
+
+		if past := baseline.durations[history.Key(test.Suite, test.Name)]; len(past) > 0 {
+			trend.Samples = len(past)
+			trend.P50 = percentile(past, 50)
+			trend.P95 = percentile(past, 95)
+			if trend.P50 > 0 {
+				trend.Ratio = float64(trend.Duration) / float64(trend.P50)
+			}
+			trend.Regressed = test.Status == ctrf.TestPassed &&
+				float64(trend.Duration) > options.Factor*float64(trend.P50) &&
+				trend.Duration-trend.P50 >= options.MinDelta
+		}
+		if trend.Regressed {
+			analysis.Regressions = append(analysis.Regressions, trend)
+		}
+
+		if len(test.Suite) > 0 && !strings.Contains(test.Name, "/") {
+			pkg, ok := packages[test.Suite[0]]
+			if !ok {
+				pkg = &PackageDuration{Package: test.Suite[0]}
+				packages[test.Suite[0]] = pkg
+			}
+			pkg.Duration += test.Duration
+		}
+	}
+
+	sort.SliceStable(analysis.Regressions, func(i, j int) bool {
+		return analysis.Regressions[i].Ratio > analysis.Regressions[j].Ratio
+	})
+
+	sort.SliceStable(trends, func(i, j int) bool {
+		return trends[i].Duration > trends[j].Duration
+	})
+	analysis.SlowestTests = top(trends, options.Top)
+
+	for _, pkg := range packages {
+		analysis.SlowestPackages = append(analysis.SlowestPackages, pkg)
+	}
+	sort.Slice(analysis.SlowestPackages, func(i, j int) bool {
+		if analysis.SlowestPackages[i].Duration != analysis.SlowestPackages[j].Duration {
+			return analysis.SlowestPackages[i].Duration > analysis.SlowestPackages[j].Duration
+		}
+
+		return analysis.SlowestPackages[i].Package < analysis.SlowestPackages[j].Package
+	})
+	analysis.SlowestPackages = top(analysis.SlowestPackages, options.Top)
+
+	return analysis
+}
+
+// WriteText writes the analysis as plain text.
+func (analysis *Analysis) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Regressions (slower than %gx their median over %d run(s)):\n", analysis.Factor, analysis.BaselineRuns)
+	if len(analysis.Regressions) == 0 {
+		fmt.Fprintln(tw, "  none")
+	}
+	for _, trend := range analysis.Regressions {
+		fmt.Fprintf(tw, "  %s\t%s\tp50 %s\tp95 %s\t%.1fx\n",
+			history.Key(trend.Suite, trend.Name), millis(trend.Duration), millis(trend.P50), millis(trend.P95), trend.Ratio)
+	}
+
+	fmt.Fprintln(tw, "\nSlowest tests:")
+	for _, trend := range analysis.SlowestTests {
+		fmt.Fprintf(tw, "  %s\t%s\n", history.Key(trend.Suite, trend.Name), millis(trend.Duration))
+	}
+
+	fmt.Fprintln(tw, "\nSlowest packages:")
+	for _, pkg := range analysis.SlowestPackages {
+		fmt.Fprintf(tw, "  %s\t%s\n", pkg.Package, millis(pkg.Duration))
+	}
+
+	return tw.Flush()
+}
+
+// WriteMarkdown writes the analysis as Markdown tables.
+func (analysis *Analysis) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### Duration regressions\n\nTests slower than %gx their median duration over %d run(s).\n\n", analysis.Factor, analysis.BaselineRuns)
+	if len(analysis.Regressions) == 0 {
+		b.WriteString("No regression.\n")
+	} else {
+		b.WriteString("| Test | Duration | p50 | p95 | Ratio |\n| --- | ---: | ---: | ---: | ---: |\n")
+		for _, trend := range analysis.Regressions {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %.1fx |\n",
+				history.Key(trend.Suite, trend.Name), millis(trend.Duration), millis(trend.P50), millis(trend.P95), trend.Ratio)
+		}
+	}
+
+	b.WriteString("\n### Slowest tests\n\n| Test | Duration |\n| --- | ---: |\n")
+	for _, trend := range analysis.SlowestTests {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", history.Key(trend.Suite, trend.Name), millis(trend.Duration))
+	}
+
+	b.WriteString("\n### Slowest packages\n\n| Package | Duration |\n| --- | ---: |\n")
+	for _, pkg := range analysis.SlowestPackages {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", pkg.Package, millis(pkg.Duration))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// percentile returns the p-th percentile of the values, with the nearest-rank method.
+func percentile(values []int64, p int) int64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func top[T any](values []T, n int) []T {
+	if n > 0 && len(values) > n {
+		return values[:n]
+	}
+
+	return values
+}
+
+func millis(duration int64) string {
+	return (time.Duration(duration) * time.Millisecond).String()
+}
@@ -0,0 +1,99 @@
+package trend_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/trend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	baseline := trend.NewBaseline()
+	for _, durations := range [][]int64{{100, 10, 500}, {120, 12, 400}, {110, 11, 450}, {130, 9, 0}} {
+		baseline.AddReport(trendReport(durations...))
+	}
+	baseline.AddRun(history.Run{Tests: []history.TestRun{
+		{Suite: []string{"pkg/a"}, Name: "TestA", Status: ctrf.TestFailed, Duration: 10000},
+	}})
+
+	current := trendReport(300, 40, 450)
+	current.Results.Tests = append(current.Results.Tests, &ctrf.TestResult{
+		Suite: []string{"pkg/b"}, Name: "TestNew", Status: ctrf.TestPassed, Duration: 5,
+	})
+
+	analysis := trend.Analyze(current, baseline, trend.DefaultOptions)
+
+	t.Run("should compute percentiles and flag regressions", func(t *testing.T) {
+		require.Len(t, analysis.Regressions, 1, "TestB is 4x slower, but only by 30ms")
+		assert.Equal(t, &trend.TestTrend{
+			Suite: []string{"pkg/a"}, Name: "TestA", Duration: 300,
+			Samples: 4, P50: 110, P95: 130, Ratio: 300.0 / 110, Regressed: true,
+		}, analysis.Regressions[0])
+		assert.Equal(t, 5, analysis.BaselineRuns)
+	})
+
+	t.Run("should list the slowest tests and packages", func(t *testing.T) {
+		names := make([]string, 0, len(analysis.SlowestTests))
+		for _, test := range analysis.SlowestTests {
+			names = append(names, test.Name)
+		}
+		assert.Equal(t, []string{"TestC", "TestA", "TestB", "TestC/sub", "TestNew"}, names)
+		assert.Zero(t, analysis.SlowestTests[4].Samples)
+
+		assert.Equal(t, []*trend.PackageDuration{
+			{Package: "pkg/a", Duration: 790},
+			{Package: "pkg/b", Duration: 5},
+		}, analysis.SlowestPackages)
+	})
+
+	t.Run("should keep the top entries only", func(t *testing.T) {
+		limited := trend.Analyze(current, baseline, trend.Options{Factor: 2, Top: 1})
+
+		require.Len(t, limited.SlowestTests, 1)
+		require.Len(t, limited.SlowestPackages, 1)
+		assert.Len(t, limited.Regressions, 2, "without a minimum delta, TestB is a regression too")
+	})
+
+	t.Run("should render text and Markdown", func(t *testing.T) {
+		var text bytes.Buffer
+		require.NoError(t, trend.Analyze(current, baseline, trend.Options{Factor: 2, MinDelta: 100, Top: 2}).WriteText(&text))
+		assert.Equal(t, `Regressions (slower than 2x their median over 5 run(s)):
+  pkg/a.TestA  300ms  p50 110ms  p95 130ms  2.7x
+
+Slowest tests:
+  pkg/a.TestC  450ms
+  pkg/a.TestA  300ms
+
+Slowest packages:
+  pkg/a  790ms
+  pkg/b  5ms
+`, text.String())
+
+		var markdown bytes.Buffer
+		require.NoError(t, analysis.WriteMarkdown(&markdown))
+		assert.Contains(t, markdown.String(), "| `pkg/a.TestA` | 300ms | 110ms | 130ms | 2.7x |\n")
+		assert.Contains(t, markdown.String(), "| `pkg/b` | 5ms |\n")
+	})
+}
+
+// trendReport builds a passed report of tests TestA, TestB, TestC and its subtest, with the given durations.
+func trendReport(durations ...int64) *ctrf.Report {
+	report := ctrf.NewReport("gotest", nil)
+	for i, duration := range durations {
+		report.Results.Tests = append(report.Results.Tests, &ctrf.TestResult{
+			Suite:    []string{"pkg/a"},
+			Name:     "Test" + string(rune('A'+i)),
+			Status:   ctrf.TestPassed,
+			Duration: duration,
+		})
+	}
+	report.Results.Tests = append(report.Results.Tests, &ctrf.TestResult{
+		Suite: []string{"pkg/a"}, Name: "TestC/sub", Status: ctrf.TestPassed, Duration: 20,
+	})
+
+	return report
+}
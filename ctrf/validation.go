@@ -0,0 +1,146 @@
+package ctrf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Codes of the validation errors.
+const (
+	// ValidationMissing reports a required property that is missing.
+	ValidationMissing = "missing"
+
+	// ValidationInvalid reports a property which value is invalid, e.g. a negative count.
+	ValidationInvalid = "invalid"
+
+	// ValidationInconsistent reports properties that contradict each other, e.g. counts that don't add up.
+	ValidationInconsistent = "inconsistent"
+)
+
+// ValidationError is a problem of a report, found by Validate.
+type ValidationError struct {
+	Path    string `json:"path"`    // the JSON path of the property, e.g. "results.summary.tests"
+	Code    string `json:"code"`    // ValidationMissing, ValidationInvalid or ValidationInconsistent
+	Message string `json:"message"` // the description of the problem
+}
+
+func (err *ValidationError) Error() string {
+	return err.Message
+}
+
+func missingProperty(path string) *ValidationError {
+	return &ValidationError{Path: path, Code: ValidationMissing, Message: fmt.Sprintf("missing property '%s'", path)}
+}
+
+func invalidProperty(path string) *ValidationError {
+	return &ValidationError{Path: path, Code: ValidationInvalid, Message: fmt.Sprintf("invalid property '%s'", path)}
+}
+
+func inconsistentProperty(path, format string, args ...any) *ValidationError {
+	return &ValidationError{Path: path, Code: ValidationInconsistent, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidationErrors are the problems of an invalid report, as returned by Write.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+
+	return "report is invalid: " + strings.Join(messages, "; ")
+}
+
+// Is tells if one of the problems of the report is target, for errors.Is.
+func (errs ValidationErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first of the problems of the report that matches target, for errors.As.
+func (errs ValidationErrors) As(target any) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validationErrors collects the validation errors among errs.
+func validationErrors(errs []error) ValidationErrors {
+	collected := make(ValidationErrors, 0, len(errs))
+	for _, err := range errs {
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			validationErr = &ValidationError{Code: ValidationInvalid, Message: err.Error()}
+		}
+		collected = append(collected, validationErr)
+	}
+
+	return collected
+}
+
+// WriteOptions configures how a report is written.
+type WriteOptions struct {
+	// Pretty indents the JSON.
+	Pretty bool
+
+	// WriteInvalid writes a report even when it is invalid, rather than failing with its ValidationErrors.
+	WriteInvalid bool
+
+	// Warnings receives the problems of an invalid report written anyway, one per line. They are not printed when nil.
+	Warnings io.Writer
+
+	// RecordProblems records the problems of an invalid report written anyway in the "validationErrors"
+	// extra field of the report.
+	RecordProblems bool
+}
+
+// WriteWith encodes the report as JSON, with options.
+//
+// An invalid report fails with its ValidationErrors, unless opts.WriteInvalid is set.
+func (report *Report) WriteWith(w io.Writer, opts WriteOptions) error {
+	if errs := report.Validate(); len(errs) > 0 {
+		problems := validationErrors(errs)
+		if !opts.WriteInvalid {
+			return problems
+		}
+
+		if opts.Warnings != nil {
+			for _, problem := range problems {
+				fmt.Fprintf(opts.Warnings, "warning: invalid report: %s: %s\n", problem.Path, problem.Message)
+			}
+		}
+		if opts.RecordProblems {
+			if err := SetExtra(&report.Extra, "validationErrors", problems); err != nil {
+				return fmt.Errorf("error recording the problems of the report: %w", err)
+			}
+		}
+	}
+
+	return report.encode(w, opts.Pretty)
+}
+
+// WriteFileWith writes the report to a file, with options.
+func (report *Report) WriteFileWith(filePath string, opts WriteOptions) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("error writing ctrf json report: %v", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	return report.WriteWith(file, opts)
+}
@@ -0,0 +1,84 @@
+package ctrf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func invalidReport() *Report {
+	report := NewReport("gotest", nil)
+	report.Results.Summary = &Summary{Tests: 1, Passed: -1, Start: 2, Stop: 1}
+	report.Results.Tests = []*TestResult{}
+
+	return report
+}
+
+func TestValidationErrors(t *testing.T) {
+	err := invalidReport().Write(&bytes.Buffer{}, false)
+
+	var problems ValidationErrors
+	require.True(t, errors.As(err, &problems))
+	assert.Equal(t, ValidationErrors{
+		{Path: "results.summary.passed", Code: ValidationInvalid, Message: "invalid property 'results.summary.passed'"},
+		{Path: "results.summary.start", Code: ValidationInconsistent, Message: "invalid summary timestamps: start can't be greater than stop"},
+		{
+			Path: "results.summary.tests", Code: ValidationInconsistent,
+			Message: "invalid summary counts: tests (1) must be the sum of passed (-1), failed (0), pending (0), skipped (0), and other (0), i.e. -1",
+		},
+	}, problems)
+	assert.EqualError(t, err, "report is invalid: invalid property 'results.summary.passed'; "+
+		"invalid summary timestamps: start can't be greater than stop; "+
+		"invalid summary counts: tests (1) must be the sum of passed (-1), failed (0), pending (0), skipped (0), and other (0), i.e. -1")
+
+	var problem *ValidationError
+	require.True(t, errors.As(err, &problem), "errors.As should find the problems")
+	assert.Equal(t, "results.summary.passed", problem.Path)
+	assert.True(t, errors.Is(err, problems[2]), "errors.Is should find the problems")
+}
+
+func TestWriteWith(t *testing.T) {
+	t.Run("should write an invalid report anyway", func(t *testing.T) {
+		report := invalidReport()
+
+		var out, warnings bytes.Buffer
+		err := report.WriteWith(&out, WriteOptions{WriteInvalid: true, Warnings: &warnings, RecordProblems: true})
+		require.NoError(t, err)
+
+		assert.Contains(t, warnings.String(), "warning: invalid report: results.summary.passed: invalid property 'results.summary.passed'\n")
+		assert.Contains(t, warnings.String(), "warning: invalid report: results.summary.start: ")
+
+		var written struct {
+			Extra map[string][]ValidationError `json:"extra"`
+		}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &written))
+		require.Len(t, written.Extra["validationErrors"], 3)
+		assert.Equal(t, ValidationError{
+			Path: "results.summary.passed", Code: ValidationInvalid, Message: "invalid property 'results.summary.passed'",
+		}, written.Extra["validationErrors"][0])
+	})
+
+	t.Run("should not record the problems unless asked to", func(t *testing.T) {
+		report := invalidReport()
+
+		var out bytes.Buffer
+		require.NoError(t, report.WriteWith(&out, WriteOptions{WriteInvalid: true}))
+		assert.Nil(t, report.Extra)
+		assert.NotContains(t, out.String(), "validationErrors")
+	})
+
+	t.Run("should write a valid report as is", func(t *testing.T) {
+		report := NewReport("gotest", nil)
+		report.Results.Tests = []*TestResult{}
+
+		var out, warnings bytes.Buffer
+		require.NoError(t, report.WriteWith(&out, WriteOptions{Pretty: true, WriteInvalid: true, Warnings: &warnings, RecordProblems: true}))
+		assert.Empty(t, warnings.String())
+		assert.Nil(t, report.Extra)
+		assert.Contains(t, out.String(), "\n  \"results\"")
+	})
+}
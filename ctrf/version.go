@@ -0,0 +1,142 @@
+package ctrf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Versions of the CTRF specification.
+const (
+	// SpecVersion0 is the shape of the early reports: it has no insights nor baseline,
+	// no summary duration, and tests have no id, labels, attachments, outputs, AI summary, snippet, line, thread, nor insights.
+	SpecVersion0 = "0.0.0"
+
+	// SpecVersion1 is the first stable version of the specification.
+	SpecVersion1 = "1.0.0"
+)
+
+// SpecVersions lists the supported versions of the CTRF specification, from the oldest to the latest.
+var SpecVersions = []string{SpecVersion0, SpecVersion1}
+
+// IsSpecVersion tells if a version of the CTRF specification is supported.
+func IsSpecVersion(version string) bool {
+	for _, supported := range SpecVersions {
+		if version == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ConvertTo converts the report to the shape of a version of the CTRF specification.
+//
+// Upgrading a report fills in the properties that were added since its version, when they derive from the others,
+// and downgrading a report drops the properties that its version does not have.
+// A report of a newer minor version of a supported major version is left as is when it is upgraded,
+// since its new properties are optional. An empty version is the initial one.
+func (report *Report) ConvertTo(version string) error {
+	if !IsSpecVersion(version) {
+		return fmt.Errorf("unsupported ctrf spec version %q, expected one of %s", version, strings.Join(SpecVersions, ", "))
+	}
+
+	current := report.SpecVersion
+	if current == "" {
+		current = SpecVersion0
+	}
+	currentMajor, err := majorVersion(current)
+	if err != nil {
+		return err
+	}
+	if currentMajor > latestMajorVersion() {
+		return fmt.Errorf("unsupported ctrf spec version %q, newer than %s", current, SpecVersionCTRF)
+	}
+
+	targetMajor, _ := majorVersion(version)
+	switch {
+	case currentMajor > targetMajor:
+		report.downgrade()
+	case currentMajor < targetMajor:
+		report.upgrade()
+	case !IsSpecVersion(current):
+		// A newer minor or patch version of the same major version.
+		return nil
+	}
+
+	report.SpecVersion = version
+
+	return nil
+}
+
+// upgrade fills in the properties of version 1 from the properties of version 0, and counts the flaky tests
+// of the summary under their status.
+func (report *Report) upgrade() {
+	if report.Results == nil || report.Results.Summary == nil {
+		return
+	}
+
+	summary := report.Results.Summary
+	if summary.Duration == 0 && summary.Stop > summary.Start {
+		summary.Duration = summary.Stop - summary.Start
+	}
+	summary.countFlakyUnderStatus(report.Results.Tests)
+}
+
+// downgrade drops the properties that version 0 does not have, and counts the flaky tests of the summary apart.
+func (report *Report) downgrade() {
+	report.Insights = nil
+	report.Baseline = nil
+	if report.Results == nil {
+		return
+	}
+
+	if report.Results.Summary != nil {
+		report.Results.Summary.Duration = 0
+		report.Results.Summary.countFlakyApart(report.Results.Tests)
+	}
+	if env := report.Results.Environment; env != nil {
+		*env = Environment{
+			AppName:     env.AppName,
+			AppVersion:  env.AppVersion,
+			OSPlatform:  env.OSPlatform,
+			OSRelease:   env.OSRelease,
+			OSVersion:   env.OSVersion,
+			BuildName:   env.BuildName,
+			BuildNumber: env.BuildNumber,
+			Extra:       env.Extra,
+		}
+	}
+
+	for _, test := range report.Results.Tests {
+		test.ID = ""
+		test.AI = ""
+		test.Snippet = ""
+		test.Line = 0
+		test.Labels = nil
+		test.Stdout = nil
+		test.Stderr = nil
+		test.ThreadID = ""
+		test.Attachments = nil
+		test.Insights = nil
+		for i := range test.RetryAttempts {
+			test.RetryAttempts[i].Attachments = nil
+		}
+	}
+}
+
+func majorVersion(version string) (int, error) {
+	major, _, _ := strings.Cut(version, ".")
+	value, err := strconv.Atoi(major)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid ctrf spec version %q", version)
+	}
+
+	return value, nil
+}
+
+func latestMajorVersion() int {
+	major, _ := majorVersion(SpecVersionCTRF)
+
+	return major
+}
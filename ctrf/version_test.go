@@ -0,0 +1,107 @@
+package ctrf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTo(t *testing.T) {
+	t.Run("should upgrade older reports when reading them", func(t *testing.T) {
+		report, err := Read(strings.NewReader(`{
+  "reportFormat": "CTRF",
+  "specVersion": "0.0.0",
+  "results": {
+    "tool": {"name": "gotest"},
+    "summary": {"tests": 0, "passed": 0, "failed": 0, "pending": 0, "skipped": 0, "other": 0, "start": 1000, "stop": 3500},
+    "tests": []
+  }
+}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, SpecVersionCTRF, report.SpecVersion)
+		assert.Equal(t, int64(2500), report.Results.Summary.Duration)
+	})
+
+	t.Run("should count the flaky tests of older reports under their status", func(t *testing.T) {
+		// In version 0, the flaky tests were counted apart from the other statuses.
+		const flakyReport = `{
+  "reportFormat": "CTRF",
+  "specVersion": "0.0.0",
+  "results": {
+    "tool": {"name": "gotest"},
+    "summary": {"tests": 3, "passed": 1, "failed": 1, "pending": 0, "skipped": 0, "other": 0, "flaky": 1, "start": 1000, "stop": 3500},
+    "tests": [
+      {"name": "TestPass", "status": "passed", "duration": 1},
+      {"name": "TestFlaky", "status": "passed", "duration": 1, "flaky": true},
+      {"name": "TestFail", "status": "failed", "duration": 1}
+    ]
+  }
+}`
+		report, err := Read(strings.NewReader(flakyReport))
+		require.NoError(t, err)
+		require.Empty(t, report.Validate())
+		upgraded := Summary{Tests: 3, Passed: 2, Failed: 1, Flaky: 1, Start: 1000, Stop: 3500, Duration: 2500}
+		assert.Equal(t, &upgraded, report.Results.Summary)
+
+		require.NoError(t, report.ConvertTo(SpecVersion0))
+		assert.Equal(t, &Summary{Tests: 3, Passed: 1, Failed: 1, Flaky: 1, Start: 1000, Stop: 3500}, report.Results.Summary)
+		require.Empty(t, report.Validate(), "a summary of version 0 counts the flaky tests apart")
+
+		var buf strings.Builder
+		require.NoError(t, report.Write(&buf, false))
+		roundTrip, err := Read(strings.NewReader(buf.String()))
+		require.NoError(t, err)
+		assert.Equal(t, &upgraded, roundTrip.Results.Summary)
+
+		t.Run("without tests", func(t *testing.T) {
+			report, err := Read(strings.NewReader(strings.Replace(flakyReport, `"tests": [`, `"ignored": [`, 1)))
+			require.NoError(t, err)
+			assert.Equal(t, 2, report.Results.Summary.Passed, "the flaky tests eventually passed")
+
+			require.NoError(t, report.ConvertTo(SpecVersion0))
+			assert.Equal(t, 1, report.Results.Summary.Passed)
+		})
+	})
+
+	t.Run("should accept newer minor versions", func(t *testing.T) {
+		report, err := Read(strings.NewReader(`{"specVersion": "1.4.0", "results": {}}`))
+		require.NoError(t, err)
+		assert.Equal(t, "1.4.0", report.SpecVersion)
+	})
+
+	t.Run("should reject newer major versions", func(t *testing.T) {
+		_, err := Read(strings.NewReader(`{"specVersion": "2.0.0", "results": {}}`))
+		require.EqualError(t, err, `error reading ctrf json report: unsupported ctrf spec version "2.0.0", newer than 1.0.0`)
+
+		_, err = Read(strings.NewReader(`{"specVersion": "latest", "results": {}}`))
+		require.EqualError(t, err, `error reading ctrf json report: invalid ctrf spec version "latest"`)
+	})
+
+	t.Run("should downgrade to older versions", func(t *testing.T) {
+		report := NewReport("gotest", &Environment{AppName: "app", Commit: "abc123"})
+		report.Insights = &Insights{RunsAnalyzed: 3}
+		report.Baseline = &Baseline{ReportId: "previous"}
+		report.Results.Summary = &Summary{Tests: 1, Passed: 1, Stop: 10, Duration: 10}
+		report.Results.Tests = []*TestResult{{
+			Name: "TestA", Status: TestPassed, ID: "id", Stdout: []string{"out"},
+			Labels:        map[string]any{"team": "a"},
+			Attachments:   []Attachment{{Name: "log", ContentType: "text/plain", Path: "a.log"}},
+			RetryAttempts: []RetryAttempt{{Attempt: 1, Status: TestPassed, Attachments: []Attachment{{Name: "log"}}}},
+		}}
+
+		require.NoError(t, report.ConvertTo(SpecVersion0))
+
+		data, err := report.ToJson()
+		require.NoError(t, err)
+		assert.Contains(t, data, `"specVersion":"0.0.0"`)
+		for _, property := range []string{"insights", "baseline", "duration\":10", "commit", "id", "labels", "stdout", "attachments"} {
+			assert.NotContains(t, data, `"`+property)
+		}
+		assert.Equal(t, &Environment{AppName: "app"}, report.Results.Environment)
+
+		require.EqualError(t, report.ConvertTo("0.9.0"), `unsupported ctrf spec version "0.9.0", expected one of 0.0.0, 1.0.0`)
+	})
+}
@@ -0,0 +1,212 @@
+package reporter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// AttachMarker starts the lines of test output that attach a file to the test, as "ctrf:attach name=path".
+//
+// Relative paths are relative to the directory of the package of the test, where go test runs it.
+const AttachMarker = "ctrf:attach"
+
+// Logger is the part of testing.TB that Attach needs.
+type Logger interface {
+	Helper()
+	Logf(format string, args ...any)
+}
+
+// Attach attaches a file to a test, by logging the marker line that the reporter picks up.
+//
+// The file must still exist when the reporter runs, e.g. it must not be in the t.TempDir() of the test.
+func Attach(t Logger, name, path string) {
+	t.Helper()
+	t.Logf("%s %s=%s", AttachMarker, name, path)
+}
+
+// attachRegexp matches an attachment marker in a line of output, e.g. "    main_test.go:12: ctrf:attach log=out/server.log".
+var attachRegexp = regexp.MustCompile(regexp.QuoteMeta(AttachMarker) + `\s+([^=\s][^=]*?)\s*=\s*(\S.*?)\s*$`)
+
+// parseAttachment parses the attachment declared by a line of test output.
+func parseAttachment(output string) (ctrf.Attachment, bool) {
+	matches := attachRegexp.FindStringSubmatch(output)
+	if matches == nil {
+		return ctrf.Attachment{}, false
+	}
+
+	return ctrf.Attachment{Name: matches[1], Path: matches[2], ContentType: contentType(matches[2])}, true
+}
+
+// contentTypes are the content types of the files commonly attached to tests, that the system may not know.
+var contentTypes = map[string]string{
+	".har":    "application/json",
+	".log":    "text/plain",
+	".txt":    "text/plain",
+	".diff":   "text/x-diff",
+	".patch":  "text/x-diff",
+	".golden": "text/plain",
+	".json":   "application/json",
+	".png":    "image/png",
+	".yaml":   "application/yaml",
+	".yml":    "application/yaml",
+}
+
+// contentType guesses the content type of a file from its extension.
+func contentType(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if known, ok := contentTypes[ext]; ok {
+		return known
+	}
+	if guessed := mime.TypeByExtension(ext); guessed != "" {
+		return guessed
+	}
+
+	return "application/octet-stream"
+}
+
+// resolveAttachments makes the relative paths of the attachments relative to the current directory,
+// from the directory of the file of their test.
+func resolveAttachments(report *ctrf.Report) {
+	for _, test := range report.Results.Tests {
+		if test.Filepath == "" {
+			continue
+		}
+
+		dir := filepath.Dir(test.Filepath)
+		for i := range test.Attachments {
+			test.Attachments[i].Path = resolvePath(dir, test.Attachments[i].Path)
+		}
+		for i := range test.RetryAttempts {
+			for j := range test.RetryAttempts[i].Attachments {
+				test.RetryAttempts[i].Attachments[j].Path = resolvePath(dir, test.RetryAttempts[i].Attachments[j].Path)
+			}
+		}
+	}
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// CopyAttachments copies the attached files into a directory, e.g. to archive them with the report,
+// and points the attachments to their copies.
+//
+// The copies are grouped by test, and named after the attachments. Their paths are made relative to base,
+// typically the directory of the report, unless base is empty.
+// Missing files are reported as warnings, and their attachments left untouched.
+func CopyAttachments(report *ctrf.Report, dir, base string) ([]string, error) {
+	var warnings []string
+	copied := make(map[string]string)
+	missing := make(map[string]bool)
+	for _, test := range report.Results.Tests {
+		used := make(map[string]bool)
+		testDir := filepath.Join(dir, fileName(strings.Join(append(append([]string{}, test.Suite...), test.Name), ".")))
+
+		attachments := make([]*ctrf.Attachment, 0, len(test.Attachments))
+		for i := range test.Attachments {
+			attachments = append(attachments, &test.Attachments[i])
+		}
+		for i := range test.RetryAttempts {
+			for j := range test.RetryAttempts[i].Attachments {
+				attachments = append(attachments, &test.RetryAttempts[i].Attachments[j])
+			}
+		}
+
+		for _, attachment := range attachments {
+			// An attachment of a retry attempt is usually an attachment of the test too.
+			if copyPath, ok := copied[attachment.Path]; ok {
+				attachment.Path = copyPath
+
+				continue
+			}
+			if missing[attachment.Path] {
+				continue
+			}
+
+			target := filepath.Join(testDir, uniqueName(used, fileName(attachment.Name)+filepath.Ext(attachment.Path)))
+			err := copyFile(attachment.Path, target)
+			if errors.Is(err, fs.ErrNotExist) {
+				missing[attachment.Path] = true
+				warnings = append(warnings, fmt.Sprintf("attachment %q of %s: %v", attachment.Name, test.Name, err))
+
+				continue
+			}
+			if err != nil {
+				return warnings, fmt.Errorf("error copying attachments: %w", err)
+			}
+
+			if base != "" {
+				if relative, err := filepath.Rel(base, target); err == nil {
+					target = relative
+				}
+			}
+			copied[attachment.Path] = filepath.ToSlash(target)
+			attachment.Path = filepath.ToSlash(target)
+		}
+	}
+
+	return warnings, nil
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(in)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+// fileNameReplacer replaces the characters that are unsafe in file names.
+var fileNameReplacer = strings.NewReplacer("/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_", " ", "_")
+
+func fileName(name string) string {
+	name = fileNameReplacer.Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+
+	return name
+}
+
+// uniqueName suffixes a file name that was already used with a counter.
+func uniqueName(used map[string]bool, name string) string {
+	unique := name
+	ext := filepath.Ext(name)
+	for i := 2; used[unique]; i++ {
+		unique = strings.TrimSuffix(name, ext) + "-" + strconv.Itoa(i) + ext
+	}
+	used[unique] = true
+
+	return unique
+}
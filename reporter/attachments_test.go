@@ -0,0 +1,77 @@
+package reporter_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachments(t *testing.T) {
+	//nolint:lll // The test inputs are raw go test -json events
+	input := `{"Time":"2025-03-02T01:08:01.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach"}
+{"Time":"2025-03-02T01:08:01.832333869+01:00","Action":"output","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach","Output":"    attachments_test.go:6: ctrf:attach server log=out/server.log\n"}
+{"Time":"2025-03-02T01:08:01.832339962+01:00","Action":"fail","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach","Elapsed":0}
+{"Time":"2025-03-02T01:08:02.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach"}
+{"Time":"2025-03-02T01:08:02.832333869+01:00","Action":"output","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach","Output":"    attachments_test.go:6: ctrf:attach server log=out/server.log\n"}
+{"Time":"2025-03-02T01:08:02.832333869+01:00","Action":"output","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach","Output":"    attachments_test.go:7: ctrf:attach trace = /no/such/trace.har \n"}
+{"Time":"2025-03-02T01:08:02.832339962+01:00","Action":"pass","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/attachments","Test":"TestAttach","Elapsed":0}`
+
+	report, err := reporter.ParseTestResults(bytes.NewBufferString(input), false, &ctrf.Environment{})
+	require.NoError(t, err)
+
+	serverLog := ctrf.Attachment{Name: "server log", ContentType: "text/plain", Path: filepath.Join("testdata", "attachments", "out", "server.log")}
+	trace := ctrf.Attachment{Name: "trace", ContentType: "application/json", Path: "/no/such/trace.har"}
+
+	t.Run("should attach the files declared by the tests", func(t *testing.T) {
+		require.Len(t, report.Results.Tests, 1)
+		test := report.Results.Tests[0]
+
+		assert.Equal(t, []ctrf.Attachment{serverLog, serverLog, trace}, test.Attachments)
+		require.Len(t, test.RetryAttempts, 2)
+		assert.Equal(t, []ctrf.Attachment{serverLog}, test.RetryAttempts[0].Attachments)
+		assert.Equal(t, []ctrf.Attachment{serverLog, trace}, test.RetryAttempts[1].Attachments)
+	})
+
+	t.Run("should copy the attached files", func(t *testing.T) {
+		reportDir := t.TempDir()
+
+		warnings, err := reporter.CopyAttachments(report, filepath.Join(reportDir, "artifacts"), reportDir)
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], `attachment "trace" of TestAttach`)
+
+		test := report.Results.Tests[0]
+		copied := "artifacts/github.com_ctrf-io_go-ctrf-json-reporter_reporter_testdata_attachments.TestAttach/server_log.log"
+		assert.Equal(t, copied, test.Attachments[0].Path)
+		assert.Equal(t, copied, test.Attachments[1].Path)
+		assert.Equal(t, "/no/such/trace.har", test.Attachments[2].Path)
+		assert.Equal(t, copied, test.RetryAttempts[1].Attachments[0].Path)
+
+		data, err := os.ReadFile(filepath.Join(reportDir, filepath.FromSlash(copied)))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "panic: boom")
+	})
+
+	t.Run("should log the marker of an attachment", func(t *testing.T) {
+		var logger fakeLogger
+		reporter.Attach(&logger, "har", "out/requests.har")
+		assert.Equal(t, "ctrf:attach har=out/requests.har", logger.String())
+	})
+}
+
+type fakeLogger struct {
+	bytes.Buffer
+}
+
+func (l *fakeLogger) Helper() {}
+
+func (l *fakeLogger) Logf(format string, args ...any) {
+	fmt.Fprintf(l, format, args...)
+}
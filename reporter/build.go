@@ -0,0 +1,109 @@
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// BuildFailureTestName is the name of the failed test that stands for a package that did not build.
+const BuildFailureTestName = "[build failed]"
+
+// compilerErrorRegexp matches the location of a compiler error, e.g. "pkg/b/b.go:3:1: syntax error".
+var compilerErrorRegexp = regexp.MustCompile(`(?m)^\s*(\S+\.go):(\d+)(?::\d+)?: `)
+
+// buildFailures collects the compiler output of the packages, and reports the packages that failed to build
+// as failed tests.
+//
+// A package is reported by the event of its outcome, which tells the import path that failed to build (Go 1.24 or newer),
+// or else by the build-fail event itself.
+type buildFailures struct {
+	output   map[string][]string // the build output, by import path
+	failed   []TestEvent         // the build-fail events
+	reported map[string]bool     // the packages already reported
+}
+
+func newBuildFailures() *buildFailures {
+	return &buildFailures{output: make(map[string][]string), reported: make(map[string]bool)}
+}
+
+func (b *buildFailures) addOutput(event TestEvent) {
+	importPath := buildImportPath(event)
+	b.output[importPath] = append(b.output[importPath], event.Output)
+}
+
+func (b *buildFailures) addFailure(event TestEvent) {
+	b.failed = append(b.failed, event)
+}
+
+// failUnreported reports the packages of the build-fail events that no outcome of a package reported.
+func (b *buildFailures) failUnreported(builder *ctrf.ReportBuilder) {
+	for _, event := range b.failed {
+		importPath := buildImportPath(event)
+		b.fail(builder, importPath, buildPackage(importPath), event)
+	}
+}
+
+// fail adds a failed test for a package that failed because of the build of an import path, once per package.
+func (b *buildFailures) fail(builder *ctrf.ReportBuilder, importPath, pkg string, event TestEvent) {
+	if b.reported[pkg] {
+		return
+	}
+	b.reported[pkg] = true
+
+	var eventTime int64
+	if event.Time != "" {
+		var err error
+		if eventTime, err = parseTimeString(event.Time); err != nil {
+			fmt.Fprintf(os.Stderr, "error parsing build event time '%s' : %v\n", event.Time, err)
+		}
+	}
+
+	builder.AddTest(buildFailureResult(pkg, strings.Join(b.output[importPath], ""), eventTime))
+}
+
+// buildFailureResult is the failed test of a package that did not build, which message holds the compiler errors,
+// and which location is the one of the first error.
+func buildFailureResult(pkg, output string, eventTime int64) *ctrf.TestResult {
+	result := &ctrf.TestResult{
+		Suite:     []string{pkg},
+		Name:      BuildFailureTestName,
+		Status:    ctrf.TestFailed,
+		RawStatus: ActionBuildFail,
+		Message:   output,
+		Start:     eventTime,
+		Stop:      eventTime,
+	}
+	if output == "" {
+		result.Message = fmt.Sprintf("FAIL\t%s [build failed]\n", pkg)
+	}
+
+	if match := compilerErrorRegexp.FindStringSubmatch(output); match != nil {
+		result.Filepath = filepath.Clean(match[1])
+		result.Line, _ = strconv.Atoi(match[2])
+	}
+
+	return result
+}
+
+// buildImportPath returns the import path of a build event, e.g. "example.com/pkg [example.com/pkg.test]",
+// or its package with the events of Go versions that don't report it.
+func buildImportPath(event TestEvent) string {
+	if event.ImportPath != "" {
+		return event.ImportPath
+	}
+
+	return event.Package
+}
+
+// buildPackage returns the package of an import path, e.g. "example.com/pkg" for "example.com/pkg_test [example.com/pkg.test]".
+func buildPackage(importPath string) string {
+	pkg, _, _ := strings.Cut(importPath, " ")
+
+	return strings.TrimSuffix(pkg, "_test")
+}
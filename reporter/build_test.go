@@ -0,0 +1,81 @@
+package reporter_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFailures(t *testing.T) {
+	t.Run("should report the packages that failed to build, and keep going", func(t *testing.T) {
+		fixture, err := os.Open(filepath.Join("testdata", "build.json"))
+		require.NoError(t, err)
+		defer func() {
+			_ = fixture.Close()
+		}()
+
+		report, err := reporter.ParseTestResults(fixture, false, nil)
+		require.NoError(t, err)
+		require.Empty(t, report.Validate())
+		assert.Equal(t, 4, report.Results.Summary.Tests)
+		assert.Equal(t, 3, report.Results.Summary.Failed)
+		assert.Equal(t, 1, report.Results.Summary.Passed)
+
+		tests := report.Results.Tests
+		require.Len(t, tests, 4)
+		assert.Equal(t, &ctrf.TestResult{
+			Suite:     []string{"example.com/bf/bad"},
+			Name:      reporter.BuildFailureTestName,
+			Status:    ctrf.TestFailed,
+			RawStatus: reporter.ActionBuildFail,
+			Message: "# example.com/bf/bad [example.com/bf/bad.test]\n" +
+				"bad/bad.go:4:9: cannot use \"not an int\" (untyped string constant) as int value in return statement\n",
+			Filepath: filepath.Join("bad", "bad.go"),
+			Line:     4,
+			Start:    1709287200500,
+			Stop:     1709287200500,
+		}, tests[0])
+
+		assert.Equal(t, []string{"example.com/bf/dep"}, tests[1].Suite)
+		assert.Equal(t, "TestGood", tests[2].Name)
+
+		// A package fails to build when one of its dependencies does
+		assert.Equal(t, []string{"example.com/bf/usesdep"}, tests[3].Suite)
+		assert.Equal(t, "# example.com/bf/dep\ndep/dep.go:3:14: undefined: undefined\n", tests[3].Message)
+		assert.Equal(t, filepath.Join("dep", "dep.go"), tests[3].Filepath)
+		assert.Equal(t, 3, tests[3].Line)
+
+		extra := reporter.ReportExtra(report)
+		require.NotNil(t, extra)
+		assert.Len(t, extra.BuildFailures, 2)
+		require.Len(t, extra.Packages, 4)
+		assert.Equal(t, "example.com/bf/bad [example.com/bf/bad.test]", extra.Packages[0].FailedBuild)
+		assert.Equal(t, "example.com/bf/dep", extra.Packages[3].FailedBuild)
+		assert.Empty(t, extra.Packages[2].FailedBuild)
+	})
+
+	t.Run("should report build-fail events without the outcome of their package", func(t *testing.T) {
+		input := `{"Action":"build-output","Package":"pkg/b","Output":"# pkg/b\n"}
+{"Action":"build-output","Package":"pkg/b","Output":"./b.go:3:1: syntax error: non-declaration statement outside function body\n"}
+{"Action":"build-fail","Package":"pkg/b"}
+{"ImportPath":"pkg/c_test [pkg/c.test]","Action":"build-fail"}
+`
+		report, err := reporter.ParseTestResults(strings.NewReader(input), false, nil)
+		require.NoError(t, err)
+
+		tests := report.Results.Tests
+		require.Len(t, tests, 2)
+		assert.Equal(t, []string{"pkg/b"}, tests[0].Suite)
+		assert.Equal(t, "b.go", tests[0].Filepath)
+		assert.Equal(t, 3, tests[0].Line)
+		assert.Equal(t, []string{"pkg/c"}, tests[1].Suite)
+		assert.Equal(t, "FAIL\tpkg/c [build failed]\n", tests[1].Message)
+		assert.Empty(t, tests[1].Filepath)
+	})
+}
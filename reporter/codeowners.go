@@ -0,0 +1,336 @@
+package reporter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// NoOwner is the owner under which failures of tests without any code owner are grouped.
+const NoOwner = "(none)"
+
+// codeOwnersLocations are the usual locations of a CODEOWNERS file, relative to the root of a repository,
+// in the order GitHub and GitLab look them up.
+var codeOwnersLocations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+	filepath.Join(".gitlab", "CODEOWNERS"),
+}
+
+// CodeOwners maps files to their owners, as declared by a CODEOWNERS file.
+//
+// Both the GitHub and GitLab syntaxes are supported: within a section, the last matching rule wins.
+// GitLab sections (e.g. "[Docs] @docs-team") combine the owners of the last matching rule of each section.
+type CodeOwners struct {
+	root  string // the directory that patterns are relative to, i.e. the root of the repository
+	rules []codeOwnersRule
+}
+
+type codeOwnersRule struct {
+	section string
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// OwnerFailures groups the failed tests of an owner.
+type OwnerFailures struct {
+	Owner  string   `json:"owner"`
+	Failed int      `json:"failed"`
+	Tests  []string `json:"tests"`
+}
+
+// FindCodeOwners looks up a CODEOWNERS file at its usual locations, in dir and its parent directories.
+func FindCodeOwners(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, location := range codeOwnersLocations {
+			candidate := filepath.Join(dir, location)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no CODEOWNERS file found")
+		}
+		dir = parent
+	}
+}
+
+// LoadCodeOwners reads a CODEOWNERS file.
+//
+// Patterns are relative to the root of the repository, which is inferred from the location of the file.
+func LoadCodeOwners(filename string) (*CodeOwners, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CODEOWNERS file: %w", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	root, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+	switch filepath.Base(root) {
+	case ".github", ".gitlab", "docs":
+		root = filepath.Dir(root)
+	}
+
+	return ParseCodeOwners(file, root)
+}
+
+// ParseCodeOwners parses a CODEOWNERS file, with patterns relative to the root directory.
+func ParseCodeOwners(r io.Reader, root string) (*CodeOwners, error) {
+	codeOwners := &CodeOwners{root: root}
+
+	var (
+		section       string
+		sectionOwners []string
+	)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if name, owners, ok := parseSection(line); ok {
+			section, sectionOwners = name, owners
+
+			continue
+		}
+
+		fields := splitCodeOwnersLine(line)
+		pattern, err := codeOwnersPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid CODEOWNERS pattern at line %d: %w", lineNumber, err)
+		}
+
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = sectionOwners
+		}
+		codeOwners.rules = append(codeOwners.rules, codeOwnersRule{section: section, pattern: pattern, owners: owners})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading CODEOWNERS file: %w", err)
+	}
+
+	return codeOwners, nil
+}
+
+// sectionRegexp matches GitLab section headers, e.g. "^[Docs][2] @docs-team".
+var sectionRegexp = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?(.*)$`)
+
+func parseSection(line string) (string, []string, bool) {
+	match := sectionRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return "", nil, false
+	}
+
+	return strings.ToLower(match[1]), strings.Fields(match[2]), true
+}
+
+// splitCodeOwnersLine splits a rule into its pattern and owners, honoring escaped spaces in the pattern
+// and dropping trailing comments.
+func splitCodeOwnersLine(line string) []string {
+	var (
+		fields  []string
+		current strings.Builder
+	)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			current.WriteByte(c)
+			current.WriteByte(line[i+1])
+			i++
+		case c == '#':
+			i = len(line)
+		case c == ' ' || c == '\t':
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+
+	return fields
+}
+
+// codeOwnersPattern converts a gitignore-style pattern into a regular expression matching slash-separated paths.
+//
+// A pattern that doesn't contain a slash, besides a trailing one, matches at any depth. Otherwise, it is relative
+// to the root. A pattern matching a directory matches all the files below it, unless it ends with "/*".
+func codeOwnersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	directSubFiles := strings.HasSuffix(pattern, "/*") && !strings.HasSuffix(pattern, "/**/*")
+	pattern = strings.Trim(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(pattern):
+			b.WriteString(regexp.QuoteMeta(pattern[i+1 : i+2]))
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if !directSubFiles {
+		b.WriteString("(?:/.*)?")
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of a file. The path is relative to the current directory, or absolute.
+func (codeOwners *CodeOwners) Owners(path string) []string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	rel, err := filepath.Rel(codeOwners.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	// the last matching rule of each section wins
+	var sections []string
+	matches := make(map[string][]string)
+	for _, rule := range codeOwners.rules {
+		if !rule.pattern.MatchString(rel) {
+			continue
+		}
+		if _, seen := matches[rule.section]; !seen {
+			sections = append(sections, rule.section)
+		}
+		matches[rule.section] = rule.owners
+	}
+
+	var owners []string
+	for _, section := range sections {
+		owners = appendUnique(owners, matches[section]...)
+	}
+
+	return owners
+}
+
+// Apply records the owners of each test in the extra data of the reporter about the test (see TestExtra),
+// and groups failures by owner in the extra data of the reporter in the results (see Extra).
+func (codeOwners *CodeOwners) Apply(report *ctrf.Report) ([]*OwnerFailures, error) {
+	byOwner := make(map[string]*OwnerFailures)
+	for _, test := range report.Results.Tests {
+		var owners []string
+		if test.Filepath != "" {
+			owners = codeOwners.Owners(test.Filepath)
+		}
+		if len(owners) > 0 {
+			if err := UpdateResultExtra(test, func(extra *TestExtra) { extra.Owners = owners }); err != nil {
+				return nil, err
+			}
+		}
+
+		if test.Status != ctrf.TestFailed {
+			continue
+		}
+		if len(owners) == 0 {
+			owners = []string{NoOwner}
+		}
+		for _, owner := range owners {
+			failures, ok := byOwner[owner]
+			if !ok {
+				failures = &OwnerFailures{Owner: owner, Tests: []string{}}
+				byOwner[owner] = failures
+			}
+			failures.Failed++
+			failures.Tests = append(failures.Tests, testDisplayName(test))
+		}
+	}
+
+	summary := make([]*OwnerFailures, 0, len(byOwner))
+	for _, failures := range byOwner {
+		summary = append(summary, failures)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Failed != summary[j].Failed {
+			return summary[i].Failed > summary[j].Failed
+		}
+
+		return summary[i].Owner < summary[j].Owner
+	})
+
+	if err := UpdateExtra(report, func(extra *Extra) { extra.Owners = summary }); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// WriteOwnersSummary writes failures grouped by owner as text.
+func WriteOwnersSummary(w io.Writer, summary []*OwnerFailures) error {
+	if len(summary) == 0 {
+		_, err := fmt.Fprintln(w, "No failed tests.")
+
+		return err
+	}
+
+	for _, failures := range summary {
+		if _, err := fmt.Fprintf(w, "%s: %d failed test(s)\n", failures.Owner, failures.Failed); err != nil {
+			return err
+		}
+		for _, test := range failures.Tests {
+			if _, err := fmt.Fprintf(w, "  %s\n", test); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// testDisplayName qualifies the name of a test with its suite.
+func testDisplayName(test *ctrf.TestResult) string {
+	if len(test.Suite) == 0 {
+		return test.Name
+	}
+
+	return strings.Join(test.Suite, "/") + "." + test.Name
+}
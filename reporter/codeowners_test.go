@@ -0,0 +1,118 @@
+package reporter_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOwners(t *testing.T) {
+	root := t.TempDir()
+
+	t.Run("should match GitHub rules, the last matching rule winning", func(t *testing.T) {
+		codeOwners, err := reporter.ParseCodeOwners(strings.NewReader(`
+# default owners
+*                 @org/everyone
+*.go              @org/gophers
+/docs/*           docs@example.com
+apps/             @org/apps   # any apps directory
+/build/logs/      @org/ops
+/pkg/no\ space/   @org/escaped
+/internal/**/db   @org/db
+`), root)
+		require.NoError(t, err)
+
+		for path, expected := range map[string][]string{
+			"README.md":                   {"@org/everyone"},
+			"cmd/main_test.go":            {"@org/gophers"},
+			"docs/index.md":               {"docs@example.com"},
+			"docs/nested/index.md":        {"@org/everyone"},
+			"services/apps/x/y.txt":       {"@org/apps"},
+			"build/logs/today/out.log":    {"@org/ops"},
+			"pkg/no space/file.txt":       {"@org/escaped"},
+			"internal/store/db/db_test.x": {"@org/db"},
+			"internal/db/schema.sql":      {"@org/db"},
+		} {
+			assert.Equal(t, expected, codeOwners.Owners(filepath.Join(root, filepath.FromSlash(path))), path)
+		}
+
+		assert.Empty(t, codeOwners.Owners(filepath.Join(filepath.Dir(root), "outside.go")))
+	})
+
+	t.Run("should combine GitLab sections", func(t *testing.T) {
+		codeOwners, err := reporter.ParseCodeOwners(strings.NewReader(`
+* @default
+
+[Backend] @backend-team
+*.go
+/api/ @api-team
+
+^[Docs][2] @docs-team
+*.md
+`), root)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"@default", "@backend-team"}, codeOwners.Owners(filepath.Join(root, "main.go")))
+		assert.Equal(t, []string{"@default", "@api-team", "@docs-team"}, codeOwners.Owners(filepath.Join(root, "api", "README.md")))
+	})
+
+	t.Run("should find and load CODEOWNERS at its usual locations", func(t *testing.T) {
+		repo := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(repo, ".github"), 0o755))
+		require.NoError(t, os.MkdirAll(filepath.Join(repo, "pkg", "sub"), 0o755))
+		location := filepath.Join(repo, ".github", "CODEOWNERS")
+		require.NoError(t, os.WriteFile(location, []byte("/pkg/ @org/pkg\n"), 0o600))
+
+		found, err := reporter.FindCodeOwners(filepath.Join(repo, "pkg", "sub"))
+		require.NoError(t, err)
+		require.Equal(t, location, found)
+
+		codeOwners, err := reporter.LoadCodeOwners(found)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"@org/pkg"}, codeOwners.Owners(filepath.Join(repo, "pkg", "sub", "sub_test.go")))
+	})
+
+	t.Run("should group failures by owner", func(t *testing.T) {
+		codeOwners, err := reporter.ParseCodeOwners(strings.NewReader("/a/ @team-a\n/ab/ @team-a @team-b\n"), root)
+		require.NoError(t, err)
+
+		report := ctrf.NewReport("gotest", nil)
+		report.Results.Tests = []*ctrf.TestResult{
+			{Name: "TestA", Status: ctrf.TestFailed, Suite: []string{"pkg/a"}, Filepath: filepath.Join(root, "a", "a_test.go")},
+			{Name: "TestAB", Status: ctrf.TestFailed, Suite: []string{"pkg/ab"}, Filepath: filepath.Join(root, "ab", "ab_test.go")},
+			{Name: "TestPass", Status: ctrf.TestPassed, Suite: []string{"pkg/a"}, Filepath: filepath.Join(root, "a", "a_test.go")},
+			{Name: "TestNobody", Status: ctrf.TestFailed, Suite: []string{"pkg/c"}},
+		}
+
+		summary, err := codeOwners.Apply(report)
+		require.NoError(t, err)
+
+		expected := []*reporter.OwnerFailures{
+			{Owner: "@team-a", Failed: 2, Tests: []string{"pkg/a.TestA", "pkg/ab.TestAB"}},
+			{Owner: "(none)", Failed: 1, Tests: []string{"pkg/c.TestNobody"}},
+			{Owner: "@team-b", Failed: 1, Tests: []string{"pkg/ab.TestAB"}},
+		}
+		assert.Equal(t, expected, summary)
+		assert.Equal(t, expected, reporter.ReportExtra(report).Owners)
+		assert.Equal(t, &reporter.TestExtra{Owners: []string{"@team-a"}}, reporter.ResultExtra(report.Results.Tests[2]))
+		assert.Nil(t, report.Results.Tests[3].Extra)
+
+		var text bytes.Buffer
+		require.NoError(t, reporter.WriteOwnersSummary(&text, summary))
+		assert.Equal(t, `@team-a: 2 failed test(s)
+  pkg/a.TestA
+  pkg/ab.TestAB
+(none): 1 failed test(s)
+  pkg/c.TestNobody
+@team-b: 1 failed test(s)
+  pkg/ab.TestAB
+`, text.String())
+	})
+}
@@ -0,0 +1,217 @@
+package reporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// coverBlockRegexp matches a block of a cover profile, e.g. "github.com/org/repo/pkg/file.go:12.34,15.2 3 1".
+var coverBlockRegexp = regexp.MustCompile(`^(.+):(\d+\.\d+,\d+\.\d+) (\d+) (\d+)$`)
+
+// Coverage is the statement coverage of a cover profile, as produced by "go test -coverprofile".
+type Coverage struct {
+	Mode       string             `json:"mode"`
+	Statements int                `json:"statements"`
+	Covered    int                `json:"covered"`
+	Percent    float64            `json:"percent"`
+	Packages   []*PackageCoverage `json:"packages"`
+}
+
+// PackageCoverage is the statement coverage of a package.
+type PackageCoverage struct {
+	Package    string          `json:"package"`
+	Statements int             `json:"statements"`
+	Covered    int             `json:"covered"`
+	Percent    float64         `json:"percent"`
+	Files      []*FileCoverage `json:"files,omitempty"`
+}
+
+// FileCoverage is the statement coverage of a source file.
+type FileCoverage struct {
+	File       string  `json:"file"`
+	Statements int     `json:"statements"`
+	Covered    int     `json:"covered"`
+	Percent    float64 `json:"percent"`
+}
+
+// LoadCoverProfile loads a cover profile.
+func LoadCoverProfile(filename string) (*Coverage, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cover profile: %w", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	return ParseCoverProfile(file)
+}
+
+// ParseCoverProfile parses a cover profile, in any of the set, count or atomic modes.
+//
+// Concatenated profiles are supported, provided they share the same mode. A block reported several times
+// (e.g. with -coverpkg, when several test binaries cover the same package) is covered when any of its counts is.
+// Packages and files are sorted by name.
+func ParseCoverProfile(r io.Reader) (*Coverage, error) {
+	type block struct {
+		statements int
+		covered    bool
+	}
+
+	var mode string
+	blocks := make(map[string]map[string]*block) // blocks by position, by file
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "mode:") {
+			lineMode := strings.TrimSpace(strings.TrimPrefix(line, "mode:"))
+			if mode != "" && lineMode != mode {
+				return nil, fmt.Errorf("error reading cover profile at line %d: mode %q does not match mode %q", lineNumber, lineMode, mode)
+			}
+			mode = lineMode
+
+			continue
+		}
+
+		match := coverBlockRegexp.FindStringSubmatch(line)
+		if match == nil || mode == "" {
+			return nil, fmt.Errorf("error reading cover profile at line %d: invalid line %q", lineNumber, line)
+		}
+		statements, _ := strconv.Atoi(match[3])
+		count, _ := strconv.Atoi(match[4])
+
+		file, position := match[1], match[2]
+		if blocks[file] == nil {
+			blocks[file] = make(map[string]*block)
+		}
+		b, ok := blocks[file][position]
+		if !ok {
+			b = &block{statements: statements}
+			blocks[file][position] = b
+		}
+		b.covered = b.covered || count > 0
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading cover profile: %w", err)
+	}
+
+	coverage := &Coverage{Mode: mode, Packages: []*PackageCoverage{}}
+	packages := make(map[string]*PackageCoverage)
+	for file, fileBlocks := range blocks {
+		fileCoverage := &FileCoverage{File: file}
+		for _, b := range fileBlocks {
+			fileCoverage.Statements += b.statements
+			if b.covered {
+				fileCoverage.Covered += b.statements
+			}
+		}
+		fileCoverage.Percent = percent(fileCoverage.Covered, fileCoverage.Statements)
+
+		pkg, ok := packages[path.Dir(file)]
+		if !ok {
+			pkg = &PackageCoverage{Package: path.Dir(file)}
+			packages[pkg.Package] = pkg
+			coverage.Packages = append(coverage.Packages, pkg)
+		}
+		pkg.Statements += fileCoverage.Statements
+		pkg.Covered += fileCoverage.Covered
+		pkg.Files = append(pkg.Files, fileCoverage)
+	}
+
+	sort.Slice(coverage.Packages, func(i, j int) bool {
+		return coverage.Packages[i].Package < coverage.Packages[j].Package
+	})
+	for _, pkg := range coverage.Packages {
+		sort.Slice(pkg.Files, func(i, j int) bool {
+			return pkg.Files[i].File < pkg.Files[j].File
+		})
+		pkg.Percent = percent(pkg.Covered, pkg.Statements)
+
+		coverage.Statements += pkg.Statements
+		coverage.Covered += pkg.Covered
+	}
+	coverage.Percent = percent(coverage.Covered, coverage.Statements)
+
+	return coverage, nil
+}
+
+// Apply adds the coverage to the extra data of the reporter in the results of a report (see Extra).
+//
+// The per-file breakdown is only kept with files.
+func (coverage *Coverage) Apply(report *ctrf.Report, files bool) error {
+	if !files {
+		stripped := *coverage
+		stripped.Packages = make([]*PackageCoverage, 0, len(coverage.Packages))
+		for _, pkg := range coverage.Packages {
+			strippedPkg := *pkg
+			strippedPkg.Files = nil
+			stripped.Packages = append(stripped.Packages, &strippedPkg)
+		}
+		coverage = &stripped
+	}
+
+	return UpdateExtra(report, func(extra *Extra) {
+		extra.Coverage = coverage
+	})
+}
+
+// ReportCoverage returns the coverage added to a report by Apply, or nil.
+func ReportCoverage(report *ctrf.Report) *Coverage {
+	extra := ReportExtra(report)
+	if extra == nil {
+		return nil
+	}
+
+	return extra.Coverage
+}
+
+// PackageCoverages returns the statement coverage (in percent) of each package of a report, or nil.
+//
+// The coverage printed by go test -cover takes precedence over the coverage of the profile added by Apply.
+func PackageCoverages(report *ctrf.Report) map[string]float64 {
+	extra := ReportExtra(report)
+	if extra == nil {
+		return nil
+	}
+
+	var coverages map[string]float64
+	if coverage := ReportCoverage(report); coverage != nil {
+		coverages = make(map[string]float64, len(coverage.Packages))
+		for _, pkg := range coverage.Packages {
+			coverages[pkg.Package] = pkg.Percent
+		}
+	}
+
+	for pkg, printedPercent := range extra.PackageCoverage {
+		if coverages == nil {
+			coverages = make(map[string]float64, len(extra.PackageCoverage))
+		}
+		coverages[pkg] = printedPercent
+	}
+
+	return coverages
+}
+
+// percent returns the percentage of covered statements. Without statements, the coverage is complete.
+func percent(covered, statements int) float64 {
+	if statements == 0 {
+		return 100
+	}
+
+	return 100 * float64(covered) / float64(statements)
+}
@@ -0,0 +1,91 @@
+package reporter_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoverProfile(t *testing.T) {
+	t.Run("should compute the coverage of packages and files", func(t *testing.T) {
+		coverage, err := reporter.LoadCoverProfile(filepath.Join("testdata", "coverage", "coverage.out"))
+		require.NoError(t, err)
+
+		assert.Equal(t, &reporter.Coverage{
+			Mode: "atomic", Statements: 10, Covered: 9, Percent: 90,
+			Packages: []*reporter.PackageCoverage{
+				{
+					Package: "github.com/org/repo/a", Statements: 6, Covered: 5, Percent: 100 * 5.0 / 6,
+					Files: []*reporter.FileCoverage{
+						{File: "github.com/org/repo/a/a.go", Statements: 3, Covered: 2, Percent: 100 * 2.0 / 3},
+						{File: "github.com/org/repo/a/b.go", Statements: 3, Covered: 3, Percent: 100},
+					},
+				},
+				{
+					Package: "github.com/org/repo/b", Statements: 4, Covered: 4, Percent: 100,
+					Files: []*reporter.FileCoverage{
+						{File: "github.com/org/repo/b/b.go", Statements: 4, Covered: 4, Percent: 100},
+					},
+				},
+			},
+		}, coverage)
+	})
+
+	t.Run("should add the coverage to a report", func(t *testing.T) {
+		coverage, err := reporter.ParseCoverProfile(strings.NewReader("mode: set\npkg/a.go:1.1,2.2 1 1\n"))
+		require.NoError(t, err)
+		report := ctrf.NewReport("gotest", nil)
+
+		require.NoError(t, coverage.Apply(report, false))
+
+		applied := reporter.ReportCoverage(report)
+		require.NotNil(t, applied)
+		assert.InDelta(t, 100.0, applied.Percent, 0)
+		assert.Nil(t, applied.Packages[0].Files)
+		assert.NotNil(t, coverage.Packages[0].Files, "the coverage itself is left untouched")
+	})
+
+	t.Run("should parse the coverage printed by go test -cover", func(t *testing.T) {
+		input := `{"Action":"start","Package":"pkg/a"}
+{"Action":"output","Package":"pkg/a","Output":"coverage: 83.2% of statements\n"}
+{"Action":"output","Package":"pkg/a","Output":"ok  \tpkg/a\t0.005s\tcoverage: 83.2% of statements\n"}
+{"Action":"pass","Package":"pkg/a","Elapsed":0.005}
+{"Action":"output","Package":"pkg/b","Output":"coverage: [no statements]\n"}
+{"Action":"pass","Package":"pkg/b","Elapsed":0.001}
+{"Action":"output","Package":"pkg/c","Output":"\tpkg/c\t\tcoverage: 0.0% of statements\n"}
+{"Action":"skip","Package":"pkg/c"}
+`
+		report, err := reporter.ParseTestResults(strings.NewReader(input), false, nil)
+		require.NoError(t, err)
+
+		extra := reporter.ReportExtra(report)
+		require.NotNil(t, extra)
+		assert.Equal(t, map[string]float64{"pkg/a": 83.2, "pkg/c": 0}, extra.PackageCoverage)
+
+		packages := extra.Packages
+		require.Len(t, packages, 3)
+		require.NotNil(t, packages[0].Coverage)
+		assert.InDelta(t, 83.2, *packages[0].Coverage, 0)
+		assert.Nil(t, packages[1].Coverage)
+
+		coverage := &reporter.Coverage{Packages: []*reporter.PackageCoverage{{Package: "pkg/a", Percent: 50}, {Package: "pkg/d", Percent: 10}}}
+		require.NoError(t, coverage.Apply(report, false))
+		assert.Equal(t, map[string]float64{"pkg/a": 83.2, "pkg/c": 0, "pkg/d": 10}, reporter.PackageCoverages(report))
+	})
+
+	t.Run("should reject invalid profiles", func(t *testing.T) {
+		for profile, expected := range map[string]string{
+			"pkg/a.go:1.1,2.2 1 1\n":    "line 1: invalid line",
+			"mode: set\npkg/a.go 1 1\n": "line 2: invalid line",
+			"mode: set\nmode: count\n":  `line 2: mode "count" does not match mode "set"`,
+		} {
+			_, err := reporter.ParseCoverProfile(strings.NewReader(profile))
+			require.ErrorContains(t, err, expected)
+		}
+	})
+}
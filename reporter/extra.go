@@ -0,0 +1,108 @@
+package reporter
+
+import (
+	"fmt"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/history"
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf/trend"
+)
+
+// ExtraKey is the key of the extra fields of the results and of the tests under which the reporter records its own data.
+const ExtraKey = "goReporter"
+
+// Extra is the data of the reporter about a run of go test, in the extra field of the results.
+type Extra struct {
+	// BuildOutput are the build-output events, i.e. the output of the compiler.
+	BuildOutput []TestEvent `json:"buildOutput,omitempty"`
+
+	// BuildFailures are the build-fail events of the packages that failed to build.
+	BuildFailures []TestEvent `json:"buildFailures,omitempty"`
+
+	// Packages are the outcomes of the packages.
+	Packages []*PackageResult `json:"packages,omitempty"`
+
+	// PackageCoverage is the statement coverage (in percent) printed by go test -cover, by package.
+	PackageCoverage map[string]float64 `json:"packageCoverage,omitempty"`
+
+	// Coverage is the coverage of the cover profile added by Coverage.Apply.
+	Coverage *Coverage `json:"coverage,omitempty"`
+
+	// Quarantined is the number of failed tests quarantined by Quarantine.Apply.
+	Quarantined int `json:"quarantined,omitempty"`
+
+	// Owners are the failures grouped by owner by CodeOwners.Apply.
+	Owners []*OwnerFailures `json:"owners,omitempty"`
+
+	// DurationTrend compares the durations of the tests with past runs.
+	DurationTrend *trend.Analysis `json:"durationTrend,omitempty"`
+}
+
+// TestExtra is the data of the reporter about a test, in the extra field of the test.
+type TestExtra struct {
+	// Owners are the owners of the file of the test, added by CodeOwners.Apply.
+	Owners []string `json:"owners,omitempty"`
+
+	// Quarantine is the entry that quarantined the failure of the test, added by Quarantine.Apply.
+	Quarantine *QuarantineEntry `json:"quarantine,omitempty"`
+
+	// History are the statistics of the test across runs, when they show it is flaky.
+	History *history.TestStats `json:"history,omitempty"`
+}
+
+// ReportExtra returns the data of the reporter in a report, or nil when the report has none,
+// e.g. when it was not produced by the reporter.
+//
+// The data of a report produced by ParseTestResults is returned as is, so that it can be updated in place.
+func ReportExtra(report *ctrf.Report) *Extra {
+	if report.Results == nil {
+		return nil
+	}
+
+	extra, ok := ctrf.GetExtra[*Extra](report.Results.Extra, ExtraKey)
+	if !ok {
+		return nil
+	}
+
+	return extra
+}
+
+// UpdateExtra updates the data of the reporter in a report, starting from empty data if it has none.
+func UpdateExtra(report *ctrf.Report, update func(extra *Extra)) error {
+	extra := ReportExtra(report)
+	if extra == nil {
+		extra = &Extra{}
+	}
+	update(extra)
+
+	if err := ctrf.SetExtra(&report.Results.Extra, ExtraKey, extra); err != nil {
+		return fmt.Errorf("error recording the data of the reporter in the results: %w", err)
+	}
+
+	return nil
+}
+
+// ResultExtra returns the data of the reporter about a test, or nil when the test has none.
+func ResultExtra(test *ctrf.TestResult) *TestExtra {
+	extra, ok := ctrf.GetExtra[*TestExtra](test.Extra, ExtraKey)
+	if !ok {
+		return nil
+	}
+
+	return extra
+}
+
+// UpdateResultExtra updates the data of the reporter about a test, starting from empty data if it has none.
+func UpdateResultExtra(test *ctrf.TestResult, update func(extra *TestExtra)) error {
+	extra := ResultExtra(test)
+	if extra == nil {
+		extra = &TestExtra{}
+	}
+	update(extra)
+
+	if err := ctrf.SetExtra(&test.Extra, ExtraKey, extra); err != nil {
+		return fmt.Errorf("error recording the data of the reporter in test %s: %w", test.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package reporter_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportExtra(t *testing.T) {
+	input := `{"Time":"2024-03-01T10:00:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-03-01T10:00:01Z","Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":1}
+{"Time":"2024-03-01T10:00:01Z","Action":"fail","Package":"pkg/a","Elapsed":1}
+{"Action":"build-output","ImportPath":"pkg/b","Output":"pkg/b/b.go:3:1: syntax error\n"}
+{"Action":"build-fail","Package":"pkg/b"}
+`
+	report, err := reporter.ParseTestResults(strings.NewReader(input), false, nil)
+	require.NoError(t, err)
+	require.NoError(t, (&reporter.Coverage{Percent: 50}).Apply(report, false))
+
+	extra := reporter.ReportExtra(report)
+	require.NotNil(t, extra)
+	assert.Len(t, extra.BuildOutput, 1)
+	assert.Len(t, extra.BuildFailures, 1)
+	assert.Equal(t, []*reporter.PackageResult{{Package: "pkg/a", Status: ctrf.TestFailed, Duration: 1000}}, extra.Packages)
+	assert.InDelta(t, 50, extra.Coverage.Percent, 0)
+
+	t.Run("should read the data back from a report file", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, report.Write(&buf, false))
+		assert.Contains(t, buf.String(), `"extra":{"`+reporter.ExtraKey+`":{"buildOutput":[`)
+
+		read, err := ctrf.Read(&buf)
+		require.NoError(t, err)
+		assert.Equal(t, extra, reporter.ReportExtra(read))
+	})
+
+	t.Run("should have no data without the reporter", func(t *testing.T) {
+		assert.Nil(t, reporter.ReportExtra(ctrf.NewReport("junit", nil)))
+	})
+}
@@ -0,0 +1,264 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Intervals at which Progress refreshes the tests that are running.
+const (
+	progressTerminalInterval = 500 * time.Millisecond
+	progressLineInterval     = 30 * time.Second
+)
+
+// progressMaxRunning is the number of running tests that Progress lists, the longest running first.
+const progressMaxRunning = 5
+
+// Progress shows the progress of the tests while they run, from their events: the counts of passed, failed
+// and skipped tests, the tests that are running with their elapsed time, and the failures as soon as they happen.
+//
+// On a terminal, the counts and the running tests are redrawn in place below the failures. Otherwise, the progress
+// is written line by line, e.g. to the logs of a CI.
+type Progress struct {
+	mu       sync.Mutex
+	w        io.Writer
+	terminal bool
+	now      func() time.Time
+
+	passed, failed, skipped, buildFailed int
+
+	running     map[string]*runningTest // by test key
+	output      map[string][]TestEvent  // the output of the running tests, by test key
+	buildOutput map[string][]string     // the build output, by import path
+	drawn       int                     // the number of lines drawn on the terminal, below the failures
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type runningTest struct {
+	pkg, name string
+	start     time.Time
+}
+
+// NewProgress shows the progress of the tests to w, redrawn in place when w is a terminal.
+func NewProgress(w io.Writer, terminal bool) *Progress {
+	return &Progress{
+		w:           w,
+		terminal:    terminal,
+		now:         time.Now,
+		running:     make(map[string]*runningTest),
+		output:      make(map[string][]TestEvent),
+		buildOutput: make(map[string][]string),
+	}
+}
+
+// Start refreshes the elapsed time of the running tests periodically, until Close.
+func (p *Progress) Start() {
+	interval := progressLineInterval
+	if p.terminal {
+		interval = progressTerminalInterval
+	}
+
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Refresh()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops refreshing the progress, and writes the final counts.
+func (p *Progress) Close() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clear()
+	fmt.Fprintln(p.w, p.counts())
+}
+
+// Event updates the progress with an event of go test -json, and writes the failure it reports, if any.
+func (p *Progress) Event(event TestEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case event.Action == ActionBuildOutput:
+		importPath := buildImportPath(event)
+		p.buildOutput[importPath] = append(p.buildOutput[importPath], event.Output)
+	case event.Action == ActionBuildFail:
+		p.buildFailed++
+		importPath := buildImportPath(event)
+		p.writeFailure(fmt.Sprintf("%s %s", buildPackage(importPath), BuildFailureTestName), p.buildOutput[importPath])
+		delete(p.buildOutput, importPath)
+	case event.Test == "":
+		// The outcome of a package is reported by its tests, or by its build failure.
+	case event.Action == ActionRun:
+		key := testNameKey(event.Package, event.Test)
+		p.running[key] = &runningTest{pkg: event.Package, name: event.Test, start: p.now()}
+		delete(p.output, key)
+	case event.Action == ActionOutput:
+		key := testNameKey(event.Package, event.Test)
+		p.output[key] = append(p.output[key], event)
+	case isTerminalAction(event.Action):
+		key := testNameKey(event.Package, event.Test)
+		output := p.output[key]
+		delete(p.running, key)
+		delete(p.output, key)
+
+		switch event.Action {
+		case ActionPass:
+			p.passed++
+		case ActionSkip:
+			p.skipped++
+		case ActionFail:
+			p.failed++
+			p.writeFailure(fmt.Sprintf("%s %s (%.2fs)", event.Package, event.Test, event.Elapsed), failureOutput(output))
+		}
+	}
+}
+
+// Refresh writes the counts and the running tests with their elapsed time: redrawn in place on a terminal,
+// or else on a single line when tests are running.
+func (p *Progress) Refresh() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.terminal {
+		p.draw()
+
+		return
+	}
+
+	if len(p.running) == 0 {
+		return
+	}
+	running := p.runningTests()
+	for i, test := range running {
+		running[i] = strings.TrimSpace(test)
+	}
+	fmt.Fprintf(p.w, "%s: %s\n", p.counts(), strings.Join(running, ", "))
+}
+
+// writeFailure writes the failure of a test with its output, above the progress drawn on a terminal.
+func (p *Progress) writeFailure(title string, output []string) {
+	p.clear()
+
+	fail := "--- FAIL"
+	if p.terminal {
+		fail = "\x1b[31m" + fail + "\x1b[0m"
+	}
+	fmt.Fprintf(p.w, "%s: %s\n", fail, title)
+	for _, line := range output {
+		fmt.Fprint(p.w, line)
+	}
+
+	if p.terminal {
+		p.draw()
+	}
+}
+
+// draw draws the counts and the running tests on the terminal, in place of the previous ones.
+func (p *Progress) draw() {
+	p.clear()
+
+	lines := append([]string{p.counts()}, p.runningTests()...)
+	for _, line := range lines {
+		fmt.Fprintln(p.w, line)
+	}
+	p.drawn = len(lines)
+}
+
+// clear erases the lines drawn on the terminal.
+func (p *Progress) clear() {
+	if p.drawn == 0 {
+		return
+	}
+
+	fmt.Fprintf(p.w, "\x1b[%dA\r\x1b[J", p.drawn)
+	p.drawn = 0
+}
+
+// counts describes the counts of tests, e.g. "12 passed, 1 failed, 2 skipped, 3 running".
+func (p *Progress) counts() string {
+	counts := fmt.Sprintf("%d passed, %d failed, %d skipped", p.passed, p.failed, p.skipped)
+	if p.buildFailed > 0 {
+		counts += fmt.Sprintf(", %d failed to build", p.buildFailed)
+	}
+	if len(p.running) > 0 {
+		counts += fmt.Sprintf(", %d running", len(p.running))
+	}
+
+	return counts
+}
+
+// runningTests describes the longest running tests with their elapsed time, e.g. "    pkg/a TestA (12s)".
+func (p *Progress) runningTests() []string {
+	running := make([]*runningTest, 0, len(p.running))
+	for _, test := range p.running {
+		running = append(running, test)
+	}
+	sort.Slice(running, func(i, j int) bool {
+		if !running[i].start.Equal(running[j].start) {
+			return running[i].start.Before(running[j].start)
+		}
+
+		return testNameKey(running[i].pkg, running[i].name) < testNameKey(running[j].pkg, running[j].name)
+	})
+
+	now := p.now()
+	var lines []string
+	for i, test := range running {
+		if i == progressMaxRunning {
+			lines = append(lines, fmt.Sprintf("    ... and %d more", len(running)-i))
+
+			break
+		}
+		lines = append(lines, fmt.Sprintf("    %s %s (%s)", test.pkg, test.name, now.Sub(test.start).Truncate(time.Second)))
+	}
+
+	return lines
+}
+
+// failureOutput returns the errors reported by a failed test, or its whole output, but the framing lines,
+// with Go versions that don't tell the output types.
+func failureOutput(events []TestEvent) []string {
+	var errors, output []string
+	for _, event := range events {
+		switch event.OutputType {
+		case OutputTypeError, OutputTypeErrorContinue:
+			errors = append(errors, event.Output)
+		case OutputTypeFrame:
+		default:
+			if trimmed := strings.TrimLeft(event.Output, " "); !strings.HasPrefix(trimmed, "=== ") && !strings.HasPrefix(trimmed, "--- ") {
+				output = append(output, event.Output)
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+
+	return output
+}
@@ -0,0 +1,125 @@
+package reporter_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	events := []reporter.TestEvent{
+		{Action: "build-output", ImportPath: "pkg/b", Output: "# pkg/b\n"},
+		{Action: "build-output", ImportPath: "pkg/b", Output: "b.go:3:1: syntax error\n"},
+		{Action: "build-fail", ImportPath: "pkg/b"},
+		{Action: "run", Package: "pkg/a", Test: "TestA"},
+		{Action: "run", Package: "pkg/a", Test: "TestB"},
+		{Action: "pass", Package: "pkg/a", Test: "TestA"},
+		{Action: "run", Package: "pkg/a", Test: "TestC"},
+		{Action: "output", Package: "pkg/a", Test: "TestC", Output: "=== RUN   TestC\n", OutputType: "frame"},
+		{Action: "output", Package: "pkg/a", Test: "TestC", Output: "    a_test.go:6: a log\n"},
+		{Action: "output", Package: "pkg/a", Test: "TestC", Output: "    a_test.go:13: boom\n", OutputType: "error"},
+		{Action: "output", Package: "pkg/a", Test: "TestC", Output: "--- FAIL: TestC (0.50s)\n", OutputType: "frame"},
+		{Action: "fail", Package: "pkg/a", Test: "TestC", Elapsed: 0.5},
+	}
+
+	t.Run("should write the progress line by line", func(t *testing.T) {
+		var b bytes.Buffer
+		progress := reporter.NewProgress(&b, false)
+		for _, event := range events {
+			progress.Event(event)
+		}
+		progress.Refresh()
+		progress.Event(reporter.TestEvent{Action: "skip", Package: "pkg/a", Test: "TestB"})
+		progress.Refresh()
+		progress.Close()
+
+		lines := strings.Split(b.String(), "\n")
+		require.Len(t, lines, 8)
+		assert.Equal(t, []string{
+			"--- FAIL: pkg/b [build failed]",
+			"# pkg/b",
+			"b.go:3:1: syntax error",
+			"--- FAIL: pkg/a TestC (0.50s)",
+			"    a_test.go:13: boom",
+		}, lines[:5])
+		assert.Regexp(t, `^1 passed, 1 failed, 0 skipped, 1 failed to build, 1 running: pkg/a TestB \(\d+s\)$`, lines[5])
+		assert.Equal(t, "1 passed, 1 failed, 1 skipped, 1 failed to build", lines[6], "refreshed only while tests run")
+		assert.Empty(t, lines[7])
+	})
+
+	t.Run("should redraw the progress in place on a terminal", func(t *testing.T) {
+		var b bytes.Buffer
+		progress := reporter.NewProgress(&b, true)
+		for _, event := range events[3:6] {
+			progress.Event(event)
+		}
+		progress.Refresh()
+		assert.Regexp(t, `^1 passed, 0 failed, 0 skipped, 1 running\n    pkg/a TestB \(\d+s\)\n$`, b.String())
+
+		b.Reset()
+		for _, event := range events[6:] {
+			progress.Event(event)
+		}
+		assert.Regexp(t, `^\x1b\[2A\r\x1b\[J\x1b\[31m--- FAIL\x1b\[0m: pkg/a TestC \(0.50s\)\n    a_test.go:13: boom\n`+
+			`1 passed, 1 failed, 0 skipped, 1 running\n    pkg/a TestB \(\d+s\)\n$`, b.String())
+
+		b.Reset()
+		progress.Close()
+		assert.Equal(t, "\x1b[2A\r\x1b[J1 passed, 1 failed, 0 skipped, 1 running\n", b.String())
+	})
+
+	t.Run("should tell the failures without output types", func(t *testing.T) {
+		var b bytes.Buffer
+		progress := reporter.NewProgress(&b, false)
+		for _, event := range events[6:] {
+			event.OutputType = ""
+			progress.Event(event)
+		}
+
+		assert.Equal(t, "--- FAIL: pkg/a TestC (0.50s)\n    a_test.go:6: a log\n    a_test.go:13: boom\n", b.String())
+	})
+
+	t.Run("should list the longest running tests", func(t *testing.T) {
+		var b bytes.Buffer
+		progress := reporter.NewProgress(&b, true)
+		for _, test := range []string{"Test1", "Test2", "Test3", "Test4", "Test5", "Test6", "Test7"} {
+			progress.Event(reporter.TestEvent{Action: "run", Package: "pkg/a", Test: test})
+		}
+		progress.Refresh()
+
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		require.Len(t, lines, 7)
+		assert.Equal(t, "0 passed, 0 failed, 0 skipped, 7 running", lines[0])
+		assert.Equal(t, "    ... and 2 more", lines[6])
+	})
+
+	t.Run("should stop refreshing when closed", func(t *testing.T) {
+		var b bytes.Buffer
+		progress := reporter.NewProgress(&b, true)
+		progress.Start()
+		progress.Event(events[3])
+		progress.Close()
+
+		assert.True(t, strings.HasSuffix(b.String(), "0 passed, 0 failed, 0 skipped, 1 running\n"))
+	})
+}
+
+func TestParseTestResultsWith(t *testing.T) {
+	input := `{"Time":"2024-03-01T10:00:00Z","Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Time":"2024-03-01T10:00:00Z","Action":"pass","Package":"pkg/a","Test":"TestA","Elapsed":0}
+`
+	var events []reporter.TestEvent
+	report, err := reporter.ParseTestResultsWith(strings.NewReader(input), reporter.ParseOptions{
+		OnEvent: func(event reporter.TestEvent) { events = append(events, event) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Results.Summary.Passed)
+	assert.Equal(t, []reporter.TestEvent{
+		{Time: "2024-03-01T10:00:00Z", Action: "run", Package: "pkg/a", Test: "TestA"},
+		{Time: "2024-03-01T10:00:00Z", Action: "pass", Package: "pkg/a", Test: "TestA"},
+	}, events)
+}
@@ -0,0 +1,219 @@
+package reporter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+// TagQuarantined is the tag added to the failed tests that match an active quarantine entry.
+const TagQuarantined = "quarantined"
+
+// quarantineDateLayout is the layout of the expiry date of quarantine entries.
+const quarantineDateLayout = "2006-01-02"
+
+// QuarantineEntry declares tests that are known to be flaky, and may fail without failing the build.
+type QuarantineEntry struct {
+	// Package is the import path of the package of the tests. It may contain "..." wildcards, as with go list.
+	// An empty package matches all packages.
+	Package string `json:"package,omitempty" yaml:"package,omitempty"`
+
+	// Test is a regular expression matching the whole name of the tests. It also matches
+	// the subtests of a matching test. An empty test matches all tests of the package.
+	Test string `json:"test,omitempty" yaml:"test,omitempty"`
+
+	// Owner is who is responsible for fixing the tests.
+	Owner string `json:"owner,omitempty" yaml:"owner,omitempty"`
+
+	// Reason explains why the tests are quarantined.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// Expires is the date (YYYY-MM-DD) after which the entry no longer applies.
+	// An empty date never expires.
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"`
+
+	packageRegexp *regexp.Regexp
+	testRegexp    *regexp.Regexp
+	expiresAt     time.Time
+}
+
+// Quarantine is a list of quarantined tests.
+type Quarantine struct {
+	Entries []*QuarantineEntry
+}
+
+// QuarantineResult summarizes what a Quarantine did to a report.
+type QuarantineResult struct {
+	// Quarantined is the number of failed tests that were quarantined.
+	Quarantined int
+
+	// Warnings are the problems found with the quarantine list itself, e.g. expired entries.
+	Warnings []string
+}
+
+// LoadQuarantine reads a quarantine list from a YAML or JSON file.
+//
+// The file holds a list of entries, e.g.:
+//
+//	# quarantine.yaml
+//	- package: github.com/org/repo/pkg/...
+//	  test: TestFlaky
+//	  owner: team-a
+//	  expires: 2026-12-31
+func LoadQuarantine(filename string) (*Quarantine, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading quarantine file: %w", err)
+	}
+
+	var entries []*QuarantineEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing quarantine file %s: %w", filename, err)
+	}
+
+	for i, entry := range entries {
+		if entry == nil {
+			return nil, fmt.Errorf("invalid quarantine entry #%d in %s: the entry is empty", i+1, filename)
+		}
+		if err := entry.compile(); err != nil {
+			return nil, fmt.Errorf("invalid quarantine entry #%d in %s: %w", i+1, filename, err)
+		}
+	}
+
+	return &Quarantine{Entries: entries}, nil
+}
+
+func (entry *QuarantineEntry) compile() error {
+	// an entry without a package nor a test would quarantine every failure
+	if entry.Package == "" && entry.Test == "" {
+		return errors.New("a package or a test pattern is required")
+	}
+
+	// as with go list, "x/..." matches x itself as well as its subpackages
+	pattern := regexp.QuoteMeta(entry.Package)
+	if strings.HasSuffix(entry.Package, "/...") {
+		pattern = strings.TrimSuffix(pattern, regexp.QuoteMeta("/...")) + `(?:/.*)?`
+	}
+	pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("..."), ".*")
+	entry.packageRegexp = regexp.MustCompile("^" + pattern + "$")
+
+	var err error
+	if entry.Test != "" {
+		if entry.testRegexp, err = regexp.Compile("^(?:" + entry.Test + ")$"); err != nil {
+			return fmt.Errorf("invalid test pattern %q: %w", entry.Test, err)
+		}
+	}
+
+	if entry.Expires != "" {
+		if entry.expiresAt, err = time.Parse(quarantineDateLayout, entry.Expires); err != nil {
+			return fmt.Errorf("invalid expiry date %q: %w", entry.Expires, err)
+		}
+	}
+
+	return nil
+}
+
+// expired tells if the entry no longer applies at the given time. An entry remains valid until the end of its expiry date.
+func (entry *QuarantineEntry) expired(now time.Time) bool {
+	return !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt.AddDate(0, 0, 1))
+}
+
+// matches tells if the entry covers the test, or one of its parent tests.
+func (entry *QuarantineEntry) matches(test *ctrf.TestResult) bool {
+	if entry.Package != "" && (len(test.Suite) == 0 || !entry.packageRegexp.MatchString(test.Suite[0])) {
+		return false
+	}
+
+	if entry.testRegexp == nil {
+		return true
+	}
+
+	name := test.Name
+	for {
+		if entry.testRegexp.MatchString(name) {
+			return true
+		}
+
+		i := strings.LastIndex(name, "/")
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+func (entry *QuarantineEntry) String() string {
+	description := entry.Package
+	if entry.Test != "" {
+		description += " " + entry.Test
+	}
+	if entry.Owner != "" {
+		description += " (owner: " + entry.Owner + ")"
+	}
+
+	return strings.TrimSpace(description)
+}
+
+// Apply annotates the failed tests of the report that match an active entry of the quarantine.
+//
+// Quarantined tests keep their failed status, but are tagged with TagQuarantined, and the matching entry
+// is added to the extra data of the reporter about the test (see TestExtra). The number of quarantined
+// failures is recorded in the extra data of the reporter in the results (see Extra).
+//
+// Entries that expired at the time given are ignored, and reported as warnings.
+func (q *Quarantine) Apply(report *ctrf.Report, now time.Time) (QuarantineResult, error) {
+	var result QuarantineResult
+
+	active := make([]*QuarantineEntry, 0, len(q.Entries))
+	for _, entry := range q.Entries {
+		if entry.expired(now) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("quarantine entry %s expired on %s", entry, entry.Expires))
+
+			continue
+		}
+		active = append(active, entry)
+	}
+
+	for _, test := range report.Results.Tests {
+		if test.Status != ctrf.TestFailed {
+			continue
+		}
+
+		for _, entry := range active {
+			if !entry.matches(test) {
+				continue
+			}
+
+			result.Quarantined++
+			test.Tags = appendUnique(test.Tags, TagQuarantined)
+			if err := UpdateResultExtra(test, func(extra *TestExtra) { extra.Quarantine = entry }); err != nil {
+				return result, err
+			}
+
+			break
+		}
+	}
+
+	err := UpdateExtra(report, func(extra *Extra) {
+		extra.Quarantined = result.Quarantined
+	})
+
+	return result, err
+}
+
+// QuarantinedFailures returns the number of failed tests of a report recorded as quarantined by Quarantine.Apply.
+func QuarantinedFailures(report *ctrf.Report) int {
+	extra := ReportExtra(report)
+	if extra == nil {
+		return 0
+	}
+
+	return extra.Quarantined
+}
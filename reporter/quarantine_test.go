@@ -0,0 +1,124 @@
+package reporter_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantine(t *testing.T) {
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	yamlList := `
+- package: github.com/org/repo/...
+  test: TestFlaky|TestRace
+  owner: team-a
+  expires: 2026-06-15
+- package: github.com/org/other
+  owner: team-b
+  expires: 2026-06-14
+`
+	jsonList := `[
+  {"package": "github.com/org/repo/...", "test": "TestFlaky|TestRace", "owner": "team-a", "expires": "2026-06-15"},
+  {"package": "github.com/org/other", "owner": "team-b", "expires": "2026-06-14"}
+]`
+
+	for name, content := range map[string]string{"quarantine.yaml": yamlList, "quarantine.json": jsonList} {
+		t.Run("should apply "+name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+			quarantine, err := reporter.LoadQuarantine(file)
+			require.NoError(t, err)
+			require.Len(t, quarantine.Entries, 2)
+
+			report := ctrf.NewReport("gotest", nil)
+			report.Results.Tests = []*ctrf.TestResult{
+				{Name: "TestFlaky", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo/pkg"}},
+				{Name: "TestRace/subtest", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo"}},
+				{Name: "TestRace", Status: ctrf.TestPassed, Suite: []string{"github.com/org/repo/pkg"}},
+				{Name: "TestFlakyNot", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo/pkg"}},
+				{Name: "TestExpired", Status: ctrf.TestFailed, Suite: []string{"github.com/org/other"}},
+			}
+
+			result, err := quarantine.Apply(report, now)
+			require.NoError(t, err)
+
+			assert.Equal(t, 2, result.Quarantined)
+			assert.Equal(t, []string{"quarantine entry github.com/org/other (owner: team-b) expired on 2026-06-14"}, result.Warnings)
+			assert.Equal(t, 2, reporter.QuarantinedFailures(report))
+
+			tests := report.Results.Tests
+			assert.Equal(t, []string{reporter.TagQuarantined}, tests[0].Tags)
+			assert.Equal(t, []string{reporter.TagQuarantined}, tests[1].Tags)
+			assert.Empty(t, tests[2].Tags)
+			assert.Empty(t, tests[3].Tags)
+			assert.Empty(t, tests[4].Tags)
+
+			extra := reporter.ResultExtra(tests[0])
+			require.NotNil(t, extra)
+			require.NotNil(t, extra.Quarantine)
+			assert.Equal(t, "team-a", extra.Quarantine.Owner)
+			assert.Nil(t, reporter.ResultExtra(tests[2]))
+			assert.Equal(t, ctrf.TestFailed, tests[0].Status)
+		})
+	}
+
+	t.Run("should quarantine all the tests of a package without a test pattern", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "quarantine.yaml")
+		require.NoError(t, os.WriteFile(file, []byte("- package: github.com/org/repo\n  expires: 2026-06-15\n"), 0o600))
+
+		quarantine, err := reporter.LoadQuarantine(file)
+		require.NoError(t, err)
+
+		report := ctrf.NewReport("gotest", nil)
+		report.Results.Tests = []*ctrf.TestResult{
+			{Name: "TestA", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo"}},
+			{Name: "TestB/sub", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo"}},
+			{Name: "TestC", Status: ctrf.TestFailed, Suite: []string{"github.com/org/repo/pkg"}},
+		}
+
+		result, err := quarantine.Apply(report, now)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, result.Quarantined)
+		assert.Empty(t, result.Warnings)
+		assert.Equal(t, []string{reporter.TagQuarantined}, report.Results.Tests[0].Tags)
+		assert.Equal(t, []string{reporter.TagQuarantined}, report.Results.Tests[1].Tags)
+		assert.Empty(t, report.Results.Tests[2].Tags)
+	})
+
+	t.Run("should reject invalid entries", func(t *testing.T) {
+		for _, content := range []string{
+			"- test: 'Test('",
+			"- test: TestA\n  expires: 15/06/2026",
+			"test: not a list",
+		} {
+			file := filepath.Join(t.TempDir(), "quarantine.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+			_, err := reporter.LoadQuarantine(file)
+			require.Error(t, err, content)
+		}
+	})
+
+	t.Run("should reject entries that would quarantine every failure", func(t *testing.T) {
+		for content, expected := range map[string]string{
+			"- test: TestA\n- owner: team-a\n  expires: 2026-06-15\n": "invalid quarantine entry #2 in %s: a package or a test pattern is required",
+			"- test: TestA\n-\n": "invalid quarantine entry #2 in %s: the entry is empty",
+		} {
+			file := filepath.Join(t.TempDir(), "quarantine.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+			_, err := reporter.LoadQuarantine(file)
+			require.EqualError(t, err, fmt.Sprintf(expected, file), content)
+		}
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,8 +22,27 @@ type TestEvent struct {
 	Test    string
 	Elapsed float64
 	Output  string
+
+	// ImportPath is the package being built, for the build-output and build-fail events (Go 1.24 or newer),
+	// e.g. "example.com/pkg [example.com/pkg.test]".
+	ImportPath string `json:",omitempty"`
+
+	// FailedBuild is the ImportPath of the package which build failure failed the package, for the outcome
+	// of the package (Go 1.24 or newer).
+	FailedBuild string `json:",omitempty"`
+
+	// OutputType tells what an output event is, with newer Go versions: one of OutputTypeFrame,
+	// OutputTypeError and OutputTypeErrorContinue, or empty for the other output.
+	OutputType string `json:",omitempty"`
 }
 
+// Output types of the output events.
+const (
+	OutputTypeFrame         = "frame"          // a framing line of the testing package, e.g. "=== RUN" or "--- FAIL"
+	OutputTypeError         = "error"          // the first line of an error reported by a test, e.g. with t.Error
+	OutputTypeErrorContinue = "error-continue" // the next lines of an error reported by a test
+)
+
 const (
 	ActionBuildOutput = "build-output"
 	ActionBuildFail   = "build-fail"
@@ -35,19 +55,50 @@ const (
 
 var buildOutput []string
 
+// coverageRegexp matches the statement coverage printed by go test -cover, e.g. "coverage: 83.2% of statements".
+var coverageRegexp = regexp.MustCompile(`coverage: (\d+(?:\.\d+)?)% of statements`)
+
+// PackageResult is the outcome of a package, as reported by the package-level (i.e. without a test name) events.
+//
+// When a package is run several times (e.g. when failed tests are rerun), the result reflects the last run.
+type PackageResult struct {
+	Package  string          `json:"package"`
+	Status   ctrf.TestStatus `json:"status"`
+	Duration int64           `json:"duration"`
+	Coverage *float64        `json:"coverage,omitempty"` // the statement coverage (in percent) printed with -cover
+
+	// FailedBuild is the import path of the package which build failure failed the package, if any.
+	FailedBuild string `json:"failedBuild,omitempty"`
+}
+
+// ParseOptions tunes how ParseTestResultsWith parses the events of go test -json.
+type ParseOptions struct {
+	Verbose     bool              // echo the output of the tests and builds to the standard output
+	Environment *ctrf.Environment // the environment of the report
+
+	// OnEvent, when set, is called with each event as soon as it is read, e.g. to show the progress of the tests
+	// while they run.
+	OnEvent func(event TestEvent)
+}
+
 func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.Report, error) {
+	return ParseTestResultsWith(r, ParseOptions{Verbose: verbose, Environment: env})
+}
+
+// ParseTestResultsWith parses the events of go test -json into a report, with options.
+//
+// Events are processed as they are read, so the input may be the output of tests that are still running.
+func ParseTestResultsWith(r io.Reader, opts ParseOptions) (*ctrf.Report, error) {
 	var testEvents []TestEvent
 	decoder := json.NewDecoder(r)
 
-	report := ctrf.NewReport("gotest", env)
-	report.Results.Summary.Start = time.Now().UnixNano() / int64(time.Millisecond)
+	builder := ctrf.NewReportBuilder("gotest", opts.Environment)
 
 	testStartTimes := make(map[string]int64)
-	extraMap := make(map[string]any)
-	buildOutputEvents := make([]TestEvent, 0)
-	buildFailEvents := make([]TestEvent, 0)
-
-	report.Results.Extra = extraMap
+	testAttachments := make(map[string][]ctrf.Attachment)
+	extra := &Extra{}
+	packageCoverage := make(map[string]float64)
+	builds := newBuildFailures()
 
 	for {
 		var event TestEvent
@@ -57,43 +108,56 @@ func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.R
 			return nil, err
 		}
 		testEvents = append(testEvents, event)
+		i := len(testEvents) - 1
 
-		if verbose {
+		if opts.Verbose {
 			if event.Action == ActionBuildOutput || event.Action == ActionOutput {
 				fmt.Print(event.Output)
 			}
 		}
-	}
-
-	for i, event := range testEvents {
-		// If we see any test failures, mark an overall failure in the Extra fields
-		if event.Action == ActionFail {
-			extraMap["FailedBuild"] = true
+		if opts.OnEvent != nil {
+			opts.OnEvent(event)
 		}
 
 		if event.Action == ActionBuildOutput {
-			// Capture the full events to the extras
-			buildOutputEvents = append(buildOutputEvents, event)
-			extraMap["buildOutput"] = buildOutputEvents
+			// Capture the full events to the extras, and the compiler errors of each package
+			extra.BuildOutput = append(extra.BuildOutput, event)
+			builds.addOutput(event)
 
 			// Capture the actual build output as well
 			buildOutput = append(buildOutput, event.Output)
 			continue
 		}
 
-		// Mark if we see a build failure in the extras field
+		// Mark if we see a build failure in the extras field, and report the package as a failed test.
+		// The other packages may have built fine, so we keep going.
 		if event.Action == ActionBuildFail {
-			buildFailEvents = append(buildFailEvents, event)
-			extraMap["buildFail"] = buildFailEvents
-			break
+			extra.BuildFailures = append(extra.BuildFailures, event)
+			builds.addFailure(event)
+			continue
 		}
 
 		if event.Action == ActionOutput {
 			buildOutput = append(buildOutput, event.Output)
 		}
 
-		// From this point, we only care about events associated with an actual test
+		// From this point, we only care about events associated with an actual test, besides the
+		// outcome of the package itself
 		if event.Test == "" {
+			if event.Action == ActionOutput {
+				if coverage, ok := parseCoverage(event.Output); ok {
+					packageCoverage[event.Package] = coverage
+					extra.PackageCoverage = packageCoverage
+				}
+			}
+			if isTerminalAction(event.Action) {
+				extra.Packages = updatePackageResult(extra.Packages, event, packageCoverage)
+
+				// The package failed because it, or one of its dependencies, did not build.
+				if event.FailedBuild != "" {
+					builds.fail(builder, event.FailedBuild, event.Package, event)
+				}
+			}
 			continue
 		}
 
@@ -102,13 +166,6 @@ func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.R
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error parsing test event start time '%s' : %v\n", event.Time, err)
 		} else {
-			if eventTime < report.Results.Summary.Start {
-				report.Results.Summary.Start = eventTime
-			}
-			if eventTime > report.Results.Summary.Stop {
-				report.Results.Summary.Stop = eventTime
-			}
-
 			// If this is a "run" event, record the start time of the test. We'll look this up later when
 			// we process the "pass"/"fail"/"skip" event for the test to create the TestResult
 			if event.Action == ActionRun {
@@ -116,9 +173,17 @@ func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.R
 			}
 		}
 
+		// Collect the files that the test attaches through its output, until it completes.
+		if event.Action == ActionOutput {
+			if attachment, ok := parseAttachment(event.Output); ok {
+				key := testNameKey(event.Package, event.Test)
+				testAttachments[key] = append(testAttachments[key], attachment)
+			}
+		}
+
 		// From this point on, we only deal with pass, fail, and skip events, which indicate that the
 		// test has completed, and we can create/update a TestResult for it.
-		if event.Action == ActionPass || event.Action == ActionFail || event.Action == ActionSkip {
+		if isTerminalAction(event.Action) {
 			// Look up the start time, and use this event's time as the endTime, to mark the start/stop times
 			// for the test result. Duration we get from the event.Elapsed field, which better takes into
 			// account parallel tests, setup/teardown time, etc...
@@ -127,12 +192,21 @@ func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.R
 				delete(testStartTimes, testNameKey(event.Package, event.Test))
 			}
 			stopTime := eventTime
+			attachments := testAttachments[testNameKey(event.Package, event.Test)]
+			delete(testAttachments, testNameKey(event.Package, event.Test))
 
-			// Determine the message for this test result. We only include messages on failures though,
-			// per the CTRF spec, so if this is not a failure, we pass an empty string for the message.
+			// Determine the message for this test result. We include the output of failures, per the CTRF spec,
+			// and the output of skips, which tells why the test was skipped (e.g. with t.Skip). Passed tests get
+			// an empty string for the message.
 			message := ""
-			if event.Action == ActionFail {
-				message = getMessagesForTest(testEvents, i, event.Package, event.Test, startTime)
+			line := 0
+			switch event.Action {
+			case ActionFail:
+				outputs := getOutputForTest(testEvents, i, event.Package, event.Test, startTime)
+				message = joinOutput(outputs)
+				line = errorLine(outputs)
+			case ActionSkip:
+				message = joinOutput(getOutputForTest(testEvents, i, event.Package, event.Test, startTime))
 			}
 
 			// Build the TestResult for this test event, and add it to the report.
@@ -142,97 +216,73 @@ func ParseTestResults(r io.Reader, verbose bool, env *ctrf.Environment) (*ctrf.R
 				Status:   actionToTestResult(event.Action),
 				Duration: secondsToMillis(event.Elapsed),
 				Message:  message,
+				Line:     line,
 				Start:    startTime,
 				Stop:     stopTime,
-			}
 
-			// Search through the existing results for a prior run. If this is a duplicate of an existing failure,
-			// then this is likely a retry of a potentially flaky test, so update the existing test result instead
-			// of creating a new one.
-			if existingResult := findTest(newResult.Suite, newResult.Name, report.Results.Tests); existingResult == nil {
-				addResult(report, newResult)
-			} else {
-				updateResult(report, existingResult, newResult)
+				Attachments: attachments,
 			}
+
+			// If the test already ran, then this is likely a retry of a potentially flaky test, which the builder
+			// records as another attempt of the existing test result instead of creating a new one.
+			builder.AddRetry(newResult)
 		}
 	}
 
+	builds.failUnreported(builder)
+
+	report := builder.Finish()
+	if len(testEvents) == 0 {
+		// Without any event, go test did not run at all, which is not the same as a run without tests:
+		// the report is left without tests, so that it is invalid.
+		report.Results.Tests = nil
+	}
+	report.Results.Extra = map[string]any{ExtraKey: extra}
 	enrichReportWithFilenames(report)
+	resolveAttachments(report)
 
 	return report, nil
 }
 
-// addResult adds a new test result to the report, filling out all the relevant details.
-func addResult(report *ctrf.Report, result *ctrf.TestResult) {
-	// Update the overall test count in the Summary
-	report.Results.Summary.Tests++
-
-	// Update the sub-count based on the test result status
-	switch result.Status {
-	case ctrf.TestPassed:
-		report.Results.Summary.Passed++
-	case ctrf.TestFailed:
-		report.Results.Summary.Failed++
-	case ctrf.TestSkipped:
-		report.Results.Summary.Skipped++
-	case ctrf.TestPending:
-		report.Results.Summary.Pending++
-	default:
-		report.Results.Summary.Other++
+// updatePackageResult records the outcome of a package from a package-level pass, fail or skip event,
+// along with its coverage when it was printed before.
+func updatePackageResult(results []*PackageResult, event TestEvent, coverage map[string]float64) []*PackageResult {
+	result := &PackageResult{
+		Package:     event.Package,
+		Status:      actionToTestResult(event.Action),
+		Duration:    secondsToMillis(event.Elapsed),
+		FailedBuild: event.FailedBuild,
+	}
+	if percent, ok := coverage[event.Package]; ok {
+		result.Coverage = &percent
 	}
 
-	// Append the result to the report's results
-	report.Results.Tests = append(report.Results.Tests, result)
-}
+	for i, existing := range results {
+		if existing.Package == event.Package {
+			results[i] = result
 
-func updateResult(report *ctrf.Report, oldResult, newResult *ctrf.TestResult) {
-	// If the existing result does not have a retries field, initialize it, and move the
-	// results to the first RetryAttempts object
-	if oldResult.RetryAttempts == nil {
-		oldResult.Retries = 1
-		oldResult.RetryAttempts = append(oldResult.RetryAttempts, ctrf.RetryAttempt{
-			Attempt:  1,
-			Status:   oldResult.Status,
-			Message:  oldResult.Message,
-			Duration: oldResult.Duration,
-			Start:    oldResult.Start,
-			Stop:     oldResult.Stop,
-		})
+			return results
+		}
 	}
 
-	// If this is a pass after a failure, mark the test as flaky, not failed,
-	// and update the summary counts accordingly
-	if oldResult.Status == ctrf.TestFailed && newResult.Status == ctrf.TestPassed {
-		oldResult.Flaky = true
-		report.Results.Summary.Flaky++
-		report.Results.Summary.Failed--
-	}
+	return append(results, result)
+}
 
-	// Update the overall test status to match that of the new result
-	oldResult.Status = newResult.Status
+// parseCoverage extracts the statement coverage from an output line of go test -cover.
+func parseCoverage(output string) (float64, bool) {
+	match := coverageRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return 0, false
+	}
 
-	// Clear out the top-level message on the overall result, since the messages are in the retries
-	oldResult.Message = ""
+	coverage, err := strconv.ParseFloat(match[1], 64)
 
-	// Update the times of the overall test result
-	oldResult.Duration += newResult.Duration
-	if newResult.Stop > oldResult.Stop {
-		oldResult.Stop = newResult.Stop
-	}
-	if newResult.Start < oldResult.Start {
-		oldResult.Start = newResult.Start
-	}
+	return coverage, err == nil
+}
 
-	// Now add the new attempt to the retries
-	oldResult.Retries++
-	oldResult.RetryAttempts = append(oldResult.RetryAttempts, ctrf.RetryAttempt{
-		Attempt:  oldResult.Retries,
-		Status:   newResult.Status,
-		Message:  newResult.Message,
-		Duration: newResult.Duration,
-		Start:    newResult.Start,
-		Stop:     newResult.Stop,
-	})
+// isTerminalAction tells if the action marks the completion of a test or a package.
+func isTerminalAction(action string) bool {
+	return action == ActionPass || action == ActionFail || action == ActionSkip
 }
 
 // testNameKey generates a unique key for a map lookup based on the test name and suite.
@@ -253,29 +303,12 @@ func actionToTestResult(action string) ctrf.TestStatus {
 	}
 }
 
-func sliceIsEqual(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
-	}
-	return true
-}
-
-// findTest searches through the already-parsed test results to find a matching test.
-func findTest(suite []string, name string, tests []*ctrf.TestResult) *ctrf.TestResult {
-	for _, test := range tests {
-		if sliceIsEqual(suite, test.Suite) && test.Name == name {
-			return test
-		}
-	}
-	return nil
-}
-
-func generateTestMap() map[string][]string {
+// generateTestMap walks the test files below the current directory.
+//
+// It returns the test functions declared in each file, as well as the test names found in each file
+// by a plain text search, for tests that are not declared as such (e.g. tests generated at runtime).
+func generateTestMap() (testSources, map[string][]string) {
+	sources := testSources{}
 	tests := map[string][]string{}
 
 	r := regexp.MustCompile(`Test.\w+`)
@@ -289,20 +322,34 @@ func generateTestMap() map[string][]string {
 			return nil
 		}
 
+		sources.addFile(path, data)
 		tests[path] = r.FindAllString(string(data), -1)
 
 		return nil
 	}); err != nil {
-		return tests
+		return sources, tests
 	}
 
-	return tests
+	return sources, tests
 }
 
+// enrichReportWithFilenames resolves the file of each test, and attaches the tags derived from the source of the test.
 func enrichReportWithFilenames(report *ctrf.Report) {
-	tests := generateTestMap()
+	sources, tests := generateTestMap()
 
 	for i, testResult := range report.Results.Tests {
+		var pkg string
+		if len(testResult.Suite) > 0 {
+			pkg = testResult.Suite[0]
+		}
+
+		if source := sources.resolve(pkg, testResult.Name); source != nil {
+			report.Results.Tests[i].Filepath = source.file
+			report.Results.Tests[i].Tags = appendUnique(testResult.Tags, source.tagsFor(testResult)...)
+
+			continue
+		}
+
 		for file, names := range tests {
 			for _, name := range names {
 				if strings.Contains(testResult.Name, name) {
@@ -313,8 +360,9 @@ func enrichReportWithFilenames(report *ctrf.Report) {
 	}
 }
 
-func getMessagesForTest(testEvents []TestEvent, index int, packageName, testName string, startTime int64) string {
-	var messages []string
+// getOutputForTest returns the output events of a test, up to the event at index.
+func getOutputForTest(testEvents []TestEvent, index int, packageName, testName string, startTime int64) []TestEvent {
+	var outputs []TestEvent
 	for i := index; i >= 0; i-- {
 		if testEvents[i].Package == packageName && testEvents[i].Test == testName {
 			// If we are only getting the messages for a single test retry, then we only want the messages
@@ -331,12 +379,41 @@ func getMessagesForTest(testEvents []TestEvent, index int, packageName, testName
 			}
 
 			if testEvents[i].Action == ActionOutput {
-				messages = append(messages, testEvents[i].Output)
+				outputs = append(outputs, testEvents[i])
 			}
 		}
 	}
-	reverse(messages)
-	return strings.Join(messages, "")
+	reverse(outputs)
+	return outputs
+}
+
+// joinOutput joins the output of events.
+func joinOutput(events []TestEvent) string {
+	var output strings.Builder
+	for _, event := range events {
+		output.WriteString(event.Output)
+	}
+
+	return output.String()
+}
+
+// errorLineRegexp matches the location of an error reported by a test, e.g. "    main_test.go:12: unexpected value".
+var errorLineRegexp = regexp.MustCompile(`^\s*\S+\.go:(\d+): `)
+
+// errorLine returns the line of the first error reported by a test, from the output types of newer Go versions, or 0.
+func errorLine(events []TestEvent) int {
+	for _, event := range events {
+		if event.OutputType != OutputTypeError {
+			continue
+		}
+		if match := errorLineRegexp.FindStringSubmatch(event.Output); match != nil {
+			line, _ := strconv.Atoi(match[1])
+
+			return line
+		}
+	}
+
+	return 0
 }
 
 func WriteReportToFile(filename string, report *ctrf.Report) error {
@@ -364,7 +441,7 @@ func parseTimeString(timeString string) (int64, error) {
 	return t.UnixNano() / int64(time.Millisecond), nil
 }
 
-func reverse(s []string) {
+func reverse[T any](s []T) {
 	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
 		s[i], s[j] = s[j], s[i]
 	}
@@ -2,6 +2,7 @@ package reporter_test
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
@@ -149,20 +150,84 @@ func Test_Enrich_ReporterWithUnorderedMessages(t *testing.T) {
 	assert.Equal(t, expected.Results.Tests, actual.Results.Tests)
 }
 
+func Test_Enrich_ReporterWithTags(t *testing.T) {
+	const pkg = "github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags"
+	expected := []*ctrf.TestResult{
+		{
+			Name:     "TestTagged_Slow/subtest",
+			Status:   ctrf.TestPassed,
+			Suite:    []string{pkg},
+			Filepath: "testdata/tags/tags_test.go",
+			Tags:     []string{"integration", "slow", "db"},
+			Start:    1740874081832,
+			Stop:     1740874081832,
+		},
+		{
+			Name:     "TestTagged_Slow",
+			Status:   ctrf.TestPassed,
+			Suite:    []string{pkg},
+			Filepath: "testdata/tags/tags_test.go",
+			Tags:     []string{"integration", "slow", "db"},
+			Start:    1740874081832,
+			Stop:     1740874081832,
+		},
+		{
+			Name:     "TestTagged_Short",
+			Status:   ctrf.TestSkipped,
+			Suite:    []string{pkg},
+			Filepath: "testdata/tags/tags_test.go",
+			Tags:     []string{"integration", "short"},
+			Message:  "    tags_test.go:17: skipped in short mode\n",
+			Start:    1740874081832,
+			Stop:     1740874081832,
+		},
+		{
+			Name:     "TestTagged_Platform",
+			Status:   ctrf.TestPassed,
+			Suite:    []string{pkg},
+			Filepath: "testdata/tags/platform_test.go",
+			Tags:     []string{"integration"}, // required by both sides of (integration && linux) || (integration && darwin)
+			Start:    1740874081832,
+			Stop:     1740874081832,
+		},
+	}
+
+	//nolint:lll // The test inputs are raw go test -json events
+	input := `{"Time":"2025-03-02T01:08:01.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Slow"}
+{"Time":"2025-03-02T01:08:01.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Slow/subtest"}
+{"Time":"2025-03-02T01:08:01.832339962+01:00","Action":"pass","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Slow/subtest","Elapsed":0}
+{"Time":"2025-03-02T01:08:01.832339962+01:00","Action":"pass","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Slow","Elapsed":0}
+{"Time":"2025-03-02T01:08:01.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Short"}
+{"Time":"2025-03-02T01:08:01.832333869+01:00","Action":"output","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Short","Output":"    tags_test.go:17: skipped in short mode\n"}
+{"Time":"2025-03-02T01:08:01.832339962+01:00","Action":"skip","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Short","Elapsed":0}
+{"Time":"2025-03-02T01:08:01.832309292+01:00","Action":"run","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Platform"}
+{"Time":"2025-03-02T01:08:01.832339962+01:00","Action":"pass","Package":"github.com/ctrf-io/go-ctrf-json-reporter/reporter/testdata/tags","Test":"TestTagged_Platform","Elapsed":0}`
+
+	actual, err := reporter.ParseTestResults(bytes.NewBufferString(input), false, &ctrf.Environment{})
+
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual.Results.Tests)
+}
+
 func TestDetectFlakyTests(t *testing.T) {
 	expected := &ctrf.Report{Results: &ctrf.Results{
 		Summary: &ctrf.Summary{
-			Tests:   4,
-			Passed:  1,
-			Failed:  1,
-			Skipped: 1,
-			Flaky:   1,
-			Start:   1775245677812, // The event timestamp of the first processed event
-			Stop:    1775245679646, // The event timestamp of the last processed event
-		},
-		Extra: map[string]any{
-			"FailedBuild": true,
+			Tests:    4,
+			Passed:   2, // Including the flaky test that passed on retry
+			Failed:   1,
+			Skipped:  1,
+			Flaky:    1,
+			Suites:   1,             // The package of the tests
+			Start:    1775245677812, // The event timestamp of the first processed event
+			Stop:     1775245679646, // The event timestamp of the last processed event
+			Duration: 1834,
 		},
+		Extra: map[string]any{reporter.ExtraKey: &reporter.Extra{
+			Packages: []*reporter.PackageResult{
+				// The last rerun of the package is the one that passed Test_Flaky_Flaky
+				{Package: "github.com/ctrf-io/go-ctrf-json-reporter/examples/flaky", Status: ctrf.TestPassed, Duration: 235},
+			},
+		}},
 		Tests: []*ctrf.TestResult{
 			{
 				Name:     "Test_Flaky_Pass",
@@ -203,6 +268,7 @@ func TestDetectFlakyTests(t *testing.T) {
 				Status:   ctrf.TestSkipped,
 				Suite:    []string{"github.com/ctrf-io/go-ctrf-json-reporter/examples/flaky"},
 				Filepath: "reporter_test.go",
+				Message:  "=== RUN   Test_Flaky_Skipped\n    flaky_test.go:25: This test is designed to be skipped.\n--- SKIP: Test_Flaky_Skipped (0.00s)\n",
 				Start:    1775245677914,
 				Stop:     1775245677914,
 			},
@@ -298,3 +364,46 @@ func TestDetectFlakyTests(t *testing.T) {
 	assert.Equal(t, expected.Results.Tests, actual.Results.Tests)
 	assert.Equal(t, expected.Results.Extra, actual.Results.Extra)
 }
+
+func TestErrorLine(t *testing.T) {
+	input := `{"Action":"run","Package":"pkg/a","Test":"TestA"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"=== RUN   TestA\n","OutputType":"frame"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"    a_test.go:6: a log\n"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"    a_test.go:13: boom\n","OutputType":"error"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"        on two lines\n","OutputType":"error-continue"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"    a_test.go:14: boom again\n","OutputType":"error"}
+{"Action":"output","Package":"pkg/a","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"pkg/a","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"pkg/a","Test":"TestB"}
+{"Action":"output","Package":"pkg/a","Test":"TestB","Output":"    a_test.go:20: boom\n"}
+{"Action":"fail","Package":"pkg/a","Test":"TestB","Elapsed":0}
+`
+	report, err := reporter.ParseTestResults(strings.NewReader(input), false, nil)
+	require.NoError(t, err)
+
+	tests := report.Results.Tests
+	require.Len(t, tests, 2)
+	assert.Equal(t, 13, tests[0].Line, "the line of the first error")
+	assert.Equal(t, "=== RUN   TestA\n    a_test.go:6: a log\n    a_test.go:13: boom\n        on two lines\n"+
+		"    a_test.go:14: boom again\n--- FAIL: TestA (0.00s)\n", tests[0].Message)
+	assert.Zero(t, tests[1].Line, "no line without the output types")
+}
+
+func TestPackageWithoutTests(t *testing.T) {
+	input := `{"Time":"2024-03-01T10:00:00Z","Action":"start","Package":"pkg/none"}
+{"Time":"2024-03-01T10:00:00Z","Action":"output","Package":"pkg/none","Output":"?   \tpkg/none\t[no test files]\n"}
+{"Time":"2024-03-01T10:00:00Z","Action":"skip","Package":"pkg/none","Elapsed":0}
+`
+
+	report, err := reporter.ParseTestResults(strings.NewReader(input), false, nil)
+	require.NoError(t, err)
+	assert.Empty(t, report.Validate(), "a run without tests is valid")
+	assert.Equal(t, []*ctrf.TestResult{}, report.Results.Tests)
+
+	t.Run("should leave a report without any event invalid", func(t *testing.T) {
+		report, err := reporter.ParseTestResults(strings.NewReader(""), false, nil)
+		require.NoError(t, err)
+		assert.Nil(t, report.Results.Tests)
+		assert.NotEmpty(t, report.Validate())
+	})
+}
@@ -0,0 +1,54 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FailedTests reads "go test -json" output, and returns the top-level tests that failed in each package.
+//
+// A test fails when its last outcome is a failure, or when one of its subtests failed last.
+// Since "go test -run" selects subtests through their top-level test, only top-level names are returned, sorted.
+// Packages that failed without any failed test (e.g. a build failure or a panic in TestMain) are not returned.
+func FailedTests(r io.Reader) (map[string][]string, error) {
+	outcomes := make(map[string]map[string]string)
+
+	decoder := json.NewDecoder(r)
+	for {
+		var event TestEvent
+		if err := decoder.Decode(&event); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if event.Test == "" || !isTerminalAction(event.Action) {
+			continue
+		}
+
+		if outcomes[event.Package] == nil {
+			outcomes[event.Package] = make(map[string]string)
+		}
+		outcomes[event.Package][event.Test] = event.Action
+	}
+
+	failed := make(map[string][]string)
+	for pkg, tests := range outcomes {
+		for name, action := range tests {
+			if action != ActionFail {
+				continue
+			}
+
+			topLevel := strings.SplitN(name, "/", 2)[0]
+			failed[pkg] = appendUnique(failed[pkg], topLevel)
+		}
+	}
+
+	for _, tests := range failed {
+		sort.Strings(tests)
+	}
+
+	return failed, nil
+}
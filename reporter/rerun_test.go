@@ -0,0 +1,39 @@
+package reporter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/reporter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedTests(t *testing.T) {
+	output := `{"Action":"run","Package":"pkg/a","Test":"TestPass"}
+{"Action":"pass","Package":"pkg/a","Test":"TestPass"}
+{"Action":"run","Package":"pkg/a","Test":"TestSub"}
+{"Action":"run","Package":"pkg/a","Test":"TestSub/one"}
+{"Action":"fail","Package":"pkg/a","Test":"TestSub/one"}
+{"Action":"fail","Package":"pkg/a","Test":"TestSub"}
+{"Action":"run","Package":"pkg/a","Test":"TestRetried"}
+{"Action":"fail","Package":"pkg/a","Test":"TestRetried"}
+{"Action":"run","Package":"pkg/a","Test":"TestRetried"}
+{"Action":"pass","Package":"pkg/a","Test":"TestRetried"}
+{"Action":"fail","Package":"pkg/a"}
+{"Action":"run","Package":"pkg/b","Test":"TestB"}
+{"Action":"fail","Package":"pkg/b","Test":"TestB"}
+{"Action":"fail","Package":"pkg/b"}
+{"Action":"fail","Package":"pkg/c"}
+`
+
+	failed, err := reporter.FailedTests(strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"pkg/a": {"TestSub"},
+		"pkg/b": {"TestB"},
+	}, failed)
+
+	_, err = reporter.FailedTests(strings.NewReader("not json"))
+	require.Error(t, err)
+}
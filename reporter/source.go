@@ -0,0 +1,213 @@
+package reporter
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/ctrf-io/go-ctrf-json-reporter/ctrf"
+)
+
+const (
+	// tagsDirective declares tags in the doc comment of a test function, e.g. "//ctrf:tags slow,integration".
+	tagsDirective = "//ctrf:tags"
+
+	// TagShort is the tag of the skipped tests that check testing.Short(), i.e. tests that were likely skipped by -short.
+	TagShort = "short"
+)
+
+// testSource describes a test function, as declared in a test file.
+type testSource struct {
+	file        string
+	tags        []string // tags from the build constraints of the file, then from the doc comment of the function
+	checksShort bool     // the function checks testing.Short()
+}
+
+// testSources indexes test functions by name. Several packages may declare functions with the same name.
+type testSources map[string][]*testSource
+
+// addFile records the test functions declared in a test file.
+//
+// Files that can't be parsed are ignored.
+func (sources testSources) addFile(path string, data []byte) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, data, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	fileTags := buildConstraintTags(file)
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Test") {
+			continue
+		}
+
+		source := &testSource{
+			file:        path,
+			tags:        appendUnique(append([]string(nil), fileTags...), directiveTags(fn.Doc)...),
+			checksShort: checksShort(fn.Body),
+		}
+		sources[fn.Name.Name] = append(sources[fn.Name.Name], source)
+	}
+}
+
+// resolve finds the declaration of a test (or of the top-level test of a subtest) in a package.
+//
+// Since only the import path of the package is known, the declaration is the one in the directory that
+// matches the longest suffix of the import path. It returns nil when no declaration matches the package.
+func (sources testSources) resolve(pkg, name string) *testSource {
+	topLevel := strings.SplitN(name, "/", 2)[0]
+
+	var (
+		best      *testSource
+		bestScore = -1
+	)
+	for _, source := range sources[topLevel] {
+		dir := filepath.ToSlash(filepath.Dir(source.file))
+
+		score := -1
+		switch {
+		case dir == ".":
+			score = 0
+		case pkg == dir || strings.HasSuffix(pkg, "/"+dir):
+			score = len(dir)
+		}
+
+		if score > bestScore {
+			best, bestScore = source, score
+		}
+	}
+
+	return best
+}
+
+// tagsFor returns the tags of a test result declared by this source.
+func (source *testSource) tagsFor(result *ctrf.TestResult) []string {
+	tags := source.tags
+	if source.checksShort && result.Status == ctrf.TestSkipped {
+		tags = appendUnique(append([]string(nil), tags...), TagShort)
+	}
+
+	return tags
+}
+
+// buildConstraintTags returns the tags required by the //go:build constraint of a file.
+//
+// Negated tags (e.g. "!windows") are not tags of the tests, and are ignored.
+func buildConstraintTags(file *ast.File) []string {
+	var tags []string
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+
+			expr, err := constraint.Parse(comment.Text)
+			if err != nil {
+				continue
+			}
+			tags = appendUnique(tags, positiveTags(expr)...)
+		}
+	}
+
+	return tags
+}
+
+// positiveTags returns the tags required by a build constraint expression.
+//
+// Either side of an OR is enough, so only the tags required by both sides are, e.g. none for "linux || darwin".
+func positiveTags(expr constraint.Expr) []string {
+	switch e := expr.(type) {
+	case *constraint.TagExpr:
+		return []string{e.Tag}
+	case *constraint.AndExpr:
+		return append(positiveTags(e.X), positiveTags(e.Y)...)
+	case *constraint.OrExpr:
+		var common []string
+		right := positiveTags(e.Y)
+		for _, tag := range positiveTags(e.X) {
+			if contains(right, tag) {
+				common = appendUnique(common, tag)
+			}
+		}
+
+		return common
+	default:
+		return nil
+	}
+}
+
+// directiveTags returns the tags declared by //ctrf:tags directives in a doc comment.
+func directiveTags(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+
+	var tags []string
+	for _, comment := range doc.List {
+		if !strings.HasPrefix(comment.Text, tagsDirective+" ") {
+			continue
+		}
+
+		for _, tag := range strings.Split(strings.TrimPrefix(comment.Text, tagsDirective), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = appendUnique(tags, tag)
+			}
+		}
+	}
+
+	return tags
+}
+
+// checksShort tells if a function body calls testing.Short().
+func checksShort(body *ast.BlockStmt) bool {
+	if body == nil {
+		return false
+	}
+
+	var found bool
+	ast.Inspect(body, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return !found
+		}
+
+		if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == "testing" && selector.Sel.Name == "Short" {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// appendUnique appends the values that are not already present.
+func appendUnique(values []string, newValues ...string) []string {
+	for _, value := range newValues {
+		if !contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,8 @@
+package attachments
+
+import "testing"
+
+func TestAttach(t *testing.T) {
+	t.Log("ctrf:attach server log=out/server.log")
+	t.Error("the server crashed")
+}
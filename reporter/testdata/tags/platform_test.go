@@ -0,0 +1,7 @@
+//go:build (integration && linux) || (integration && darwin)
+
+package tags
+
+import "testing"
+
+func TestTagged_Platform(t *testing.T) {}
@@ -0,0 +1,18 @@
+//go:build integration && !windows
+
+package tags
+
+import "testing"
+
+// TestTagged_Slow is tagged from its doc comment.
+//
+//ctrf:tags slow, db
+func TestTagged_Slow(t *testing.T) {
+	t.Run("subtest", func(t *testing.T) {})
+}
+
+func TestTagged_Short(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipped in short mode")
+	}
+}